@@ -0,0 +1,201 @@
+package main
+
+import (
+	"go/ast"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// bindingTags are the struct tags validated at runtime by gin and gopkg.in/go-playground/validator.v8.
+var bindingTags = []string{"binding", "validate"}
+
+// bindingFormats maps validator.v8 rules to the swagger string format they check.
+var bindingFormats = map[string]string{
+	"email":  "email",
+	"url":    "uri",
+	"uri":    "uri",
+	"uuid":   "uuid",
+	"uuid3":  "uuid3",
+	"uuid4":  "uuid4",
+	"uuid5":  "uuid5",
+	"ipv4":   "ipv4",
+	"ipv6":   "ipv6",
+	"base64": "byte",
+	"isbn10": "isbn10",
+	"isbn13": "isbn13",
+	"mac":    "mac",
+}
+
+// bindingPatterns maps validator.v8 rules to the regular expressions of gopkg.in/go-playground/validator.v8/regexes.go.
+var bindingPatterns = map[string]string{
+	"alpha":       "^[a-zA-Z]+$",
+	"alphanum":    "^[a-zA-Z0-9]+$",
+	"numeric":     "^[-+]?[0-9]+(?:\\.[0-9]+)?$",
+	"number":      "^[0-9]+$",
+	"hexadecimal": "^[0-9a-fA-F]+$",
+	"hexcolor":    "^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$",
+	"latitude":    "^[-+]?([1-8]?\\d(\\.\\d+)?|90(\\.0+)?)$",
+	"longitude":   "^[-+]?(180(\\.0+)?|((1[0-7]\\d)|([1-9]?\\d))(\\.\\d+)?)$",
+	"ssn":         `^\d{3}[- ]?\d{2}[- ]?\d{4}$`,
+}
+
+// parseBindingTag returns the validator rules of the given field, ok is false if it has no binding tag.
+func parseBindingTag(field *ast.Field) (rules []string, ok bool) {
+	if field.Tag == nil {
+		return nil, false
+	}
+	tagValue, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return nil, false
+	}
+	for _, name := range bindingTags {
+		if value, found := reflect.StructTag(tagValue).Lookup(name); found && value != "-" {
+			rules = append(rules, strings.Split(value, ",")...)
+			ok = true
+		}
+	}
+	return rules, ok
+}
+
+// applyBindingRules documents the validator rules on schema and reports whether they make the field required.
+// Rules following `dive` apply to the items of a slice or the values of a map.
+func applyBindingRules(schema *spec.Schema, rules []string) bool {
+	required := false
+	for i, rule := range rules {
+		rule = strings.TrimSpace(rule)
+		if rule == "dive" {
+			applyDiveRules(schema, rules[i+1:])
+			break
+		}
+		if strings.Contains(rule, "|") { // alternatives can not be expressed as one constraint
+			continue
+		}
+		name, param := rule, ""
+		if j := strings.Index(rule, "="); j > 0 {
+			name, param = rule[:j], rule[j+1:]
+		}
+		if name == "required" {
+			required = true
+			continue
+		}
+		if schema.Ref.String() != "" { // siblings of $ref are ignored
+			continue
+		}
+		switch name {
+		case "min", "gte":
+			setLowerBound(schema, param, false)
+		case "max", "lte":
+			setUpperBound(schema, param, false)
+		case "gt":
+			setLowerBound(schema, param, true)
+		case "lt":
+			setUpperBound(schema, param, true)
+		case "len":
+			setLowerBound(schema, param, false)
+			setUpperBound(schema, param, false)
+		case "eq":
+			if value, ok := typedValue(schema, param); ok {
+				schema.Enum = []interface{}{value}
+			}
+		case "oneof":
+			var enum []interface{}
+			for _, option := range strings.Fields(param) {
+				if value, ok := typedValue(schema, option); ok {
+					enum = append(enum, value)
+				}
+			}
+			schema.Enum = enum
+		default:
+			if format, ok := bindingFormats[name]; ok {
+				schema.Format = format
+			} else if pattern, ok := bindingPatterns[name]; ok {
+				schema.Pattern = pattern
+			}
+		}
+	}
+	return required
+}
+
+// applyDiveRules applies rules to the element schema of an array or a map.
+func applyDiveRules(schema *spec.Schema, rules []string) {
+	if schema.Items != nil && schema.Items.Schema != nil {
+		items := *schema.Items.Schema
+		applyBindingRules(&items, rules)
+		schema.Items = &spec.SchemaOrArray{Schema: &items}
+	} else if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+		values := *schema.AdditionalProperties.Schema
+		applyBindingRules(&values, rules)
+		schema.AdditionalProperties = &spec.SchemaOrBool{Allows: true, Schema: &values}
+	}
+}
+
+// setLowerBound sets the minimum, minLength, minItems or minProperties of schema depending on its type.
+func setLowerBound(schema *spec.Schema, param string, exclusive bool) {
+	if schema.Type.Contains("integer") || schema.Type.Contains("number") {
+		if value, err := strconv.ParseFloat(param, 64); err == nil {
+			schema.Minimum = &value
+			schema.ExclusiveMinimum = exclusive
+		}
+		return
+	}
+	size, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return
+	}
+	if exclusive {
+		size++
+	}
+	switch {
+	case schema.Type.Contains("string"):
+		schema.MinLength = &size
+	case schema.Type.Contains("array"):
+		schema.MinItems = &size
+	case schema.Type.Contains("object"):
+		schema.MinProperties = &size
+	}
+}
+
+// setUpperBound sets the maximum, maxLength, maxItems or maxProperties of schema depending on its type.
+func setUpperBound(schema *spec.Schema, param string, exclusive bool) {
+	if schema.Type.Contains("integer") || schema.Type.Contains("number") {
+		if value, err := strconv.ParseFloat(param, 64); err == nil {
+			schema.Maximum = &value
+			schema.ExclusiveMaximum = exclusive
+		}
+		return
+	}
+	size, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return
+	}
+	if exclusive {
+		size--
+	}
+	switch {
+	case schema.Type.Contains("string"):
+		schema.MaxLength = &size
+	case schema.Type.Contains("array"):
+		schema.MaxItems = &size
+	case schema.Type.Contains("object"):
+		schema.MaxProperties = &size
+	}
+}
+
+// typedValue converts a value written in a tag or comment to the Go type matching the schema type.
+func typedValue(schema *spec.Schema, value string) (interface{}, bool) {
+	switch {
+	case schema.Type.Contains("integer"):
+		i, err := strconv.ParseInt(value, 10, 64)
+		return i, err == nil
+	case schema.Type.Contains("number"):
+		f, err := strconv.ParseFloat(value, 64)
+		return f, err == nil
+	case schema.Type.Contains("boolean"):
+		b, err := strconv.ParseBool(value)
+		return b, err == nil
+	}
+	return value, true
+}
@@ -0,0 +1,531 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/swaggo/gin-swagger"
+	"github.com/swaggo/gin-swagger/swaggerFiles"
+	"github.com/swaggo/swag"
+	"gopkg.in/yaml.v2"
+)
+
+// errFailed ends a command that already reported why it failed with a non-zero exit status.
+var errFailed = errors.New("failed")
+
+// commands maps the subcommands to their implementation, gen being the default.
+var commands = map[string]func(args []string) error{
+	"init":     runGen,
+	"gen":      runGen,
+	"validate": runValidate,
+	"fmt":      runFmt,
+	"serve":    runServe,
+	"diff":     runDiff,
+}
+
+const usage = `usage: swagger [command] [flags]
+
+commands:
+  init, gen  parse the sources and write the spec (default)
+  validate   parse the sources and report the problems of the spec
+  fmt        align and normalize the annotations of the sources
+  serve      serve the spec, generated on every request, with swagger-ui
+  diff       compare the spec of the sources with the written one
+
+run swagger <command> -h for the flags of a command
+`
+
+// Run runs the command of the command line arguments args.
+func Run(args []string) error {
+	command := "gen"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	run, ok := commands[command]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command %s", command)
+	}
+	return run(args)
+}
+
+// stringList is a flag that can be repeated or hold comma separated values.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// options holds the flags of the commands.
+type options struct {
+	searchDirs  stringList
+	mainFile    string
+	excludes    stringList
+	propNaming  string
+	typeMapping string
+	strictPath  bool
+	format      string
+	outputGo    string
+	openAPI     string
+	output      string
+	outputName  string
+	pretty      bool
+	compact     bool
+	quiet       bool
+	verbose     bool
+	json        bool
+	addr        string
+}
+
+// parseFlags parses the flags of command from args. Every command searches the sources, the others are
+// only registered when generate is set.
+func parseFlags(command string, args []string, generate bool) (*options, *flag.FlagSet, error) {
+	opts := &options{}
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.Var(&opts.searchDirs, "dir", "use -dir ./,../model to parse other directories than the working one, repeatable")
+	flags.StringVar(&opts.mainFile, "main", "main.go", "use -main <mainfile>, relative to the first -dir")
+	flags.Var(&opts.excludes, "exclude", "use -exclude internal/mock,*_gen.go to skip paths or globs, repeatable")
+	flags.BoolVar(&opts.quiet, "quiet", false, "use -quiet to only print errors")
+	flags.BoolVar(&opts.verbose, "v", false, "use -v to print the files, routes and operations found")
+	if generate {
+		flags.StringVar(&opts.propNaming, "propNaming", SnakeCase, "use -propNaming snakecase|camelcase|pascalcase for fields without json tag")
+		flags.StringVar(&opts.typeMapping, "typeMapping", "", "use -typeMapping decimal.Decimal=number/double,bson.ObjectId=string")
+		flags.BoolVar(&opts.strictPath, "strictPathParams", false, "use -strictPathParams to require a @Param for every path param")
+		flags.StringVar(&opts.format, "format", FormatJSON, "use -format json|yaml|both")
+		flags.StringVar(&opts.outputGo, "output-go", "", "use -output-go docs to write a Go package registering the spec with swaggo/swag")
+		flags.StringVar(&opts.openAPI, "openapi", "2", "use -openapi 3 to write an OpenAPI 3.0 openapi.json instead of swagger.json")
+		flags.StringVar(&opts.output, "output", "", "use -output docs to write the spec to another directory than the first -dir")
+		flags.StringVar(&opts.outputName, "outputName", "", "use -outputName api to write api.json instead of swagger.json")
+		flags.BoolVar(&opts.pretty, "pretty", false, "use -pretty to indent the spec")
+		flags.BoolVar(&opts.compact, "compact", false, "use -compact to write the spec on a single line")
+		flags.BoolVar(&opts.json, "json", false, "use -json to print the diagnostics as JSON on stdout, for editors")
+	}
+	if command == "serve" {
+		flags.StringVar(&opts.addr, "addr", ":8080", "use -addr :8080 to serve the docs at another address")
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	if len(opts.searchDirs) == 0 {
+		opts.searchDirs = stringList{"./"}
+	}
+	for i, dir := range opts.searchDirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		opts.searchDirs[i] = abs
+	}
+	if !generate {
+		return opts, flags, nil
+	}
+	if opts.propNaming != SnakeCase && opts.propNaming != CamelCase && opts.propNaming != PascalCase {
+		return nil, nil, fmt.Errorf("unknown property naming %s, use -propNaming snakecase|camelcase|pascalcase", opts.propNaming)
+	}
+	if opts.format != FormatJSON && opts.format != FormatYAML && opts.format != FormatBoth {
+		return nil, nil, fmt.Errorf("unknown format %s, use -format json|yaml|both", opts.format)
+	}
+	if opts.openAPI != "2" && opts.openAPI != "3" {
+		return nil, nil, fmt.Errorf("unknown openapi version %s, use -openapi 2|3", opts.openAPI)
+	}
+	if opts.pretty && opts.compact {
+		return nil, nil, errors.New("use either -pretty or -compact")
+	}
+	if opts.output == "" {
+		opts.output = opts.searchDirs[0]
+	}
+	if opts.outputName == "" {
+		opts.outputName = "swagger"
+		if opts.openAPI == "3" {
+			opts.outputName = "openapi"
+		}
+	}
+	return opts, flags, nil
+}
+
+// infof prints the progress of a command unless it is quiet.
+func (opts *options) infof(format string, args ...interface{}) {
+	if !opts.quiet {
+		log.Printf(format, args...)
+	}
+}
+
+// parse parses the sources of the search dirs, the problems found are left in the Diagnostics of the parser.
+func (opts *options) parse() (*Parser, error) {
+	parser := NewParser()
+	parser.PropNamingStrategy = opts.propNaming
+	parser.StrictPathParams = opts.strictPath
+	parser.Excludes = opts.excludes
+	parser.Verbose = opts.verbose
+	if err := parser.ParseTypeMapping(opts.typeMapping); err != nil {
+		return nil, err
+	}
+	parser.ParseApis(opts.searchDirs, opts.mainFile)
+	return parser, nil
+}
+
+// report prints the diagnostics of parser, as JSON on stdout with -json or to stderr like a compiler,
+// and returns errFailed when one of them is an error. Strict reports fail on warnings as well, which
+// are then printed even with -quiet.
+func (opts *options) report(parser *Parser, strict bool) error {
+	failing := func(d Diagnostic) bool {
+		return strict || d.Severity == SeverityError
+	}
+	diagnostics := parser.Diagnostics
+	if opts.quiet {
+		diagnostics = nil
+		for _, d := range parser.Diagnostics {
+			if failing(d) {
+				diagnostics = append(diagnostics, d)
+			}
+		}
+	}
+	if opts.json {
+		if diagnostics == nil {
+			diagnostics = []Diagnostic{}
+		}
+		b, err := json.MarshalIndent(diagnostics, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+	} else {
+		wd, _ := os.Getwd()
+		WriteDiagnostics(os.Stderr, diagnostics, wd)
+	}
+	if parser.HasErrors() || (strict && len(parser.Diagnostics) > 0) {
+		return errFailed
+	}
+	return nil
+}
+
+// marshal encodes a document as JSON, indented as the flags ask.
+func (opts *options) marshal(doc interface{}) ([]byte, error) {
+	switch {
+	case opts.compact:
+		return json.Marshal(doc)
+	case opts.pretty:
+		return json.MarshalIndent(doc, "", "  ")
+	}
+	return json.MarshalIndent(doc, "", "")
+}
+
+// document returns the JSON of the spec written by the flags, swagger 2.0 or OpenAPI 3.0.
+func (opts *options) document(parser *Parser) ([]byte, error) {
+	if opts.openAPI == "3" {
+		return opts.marshal(ConvertOpenAPI(parser.swagger))
+	}
+	return opts.marshal(parser.swagger)
+}
+
+// outputFile returns the path of the spec written with the given extension.
+func (opts *options) outputFile(ext string) string {
+	return filepath.Join(opts.output, opts.outputName+ext)
+}
+
+func runGen(args []string) error {
+	opts, _, err := parseFlags("gen", args, true)
+	if err != nil {
+		return err
+	}
+	parser, err := opts.parse()
+	if err != nil {
+		return err
+	}
+	if err := opts.report(parser, false); err != nil { // an incomplete spec is not written
+		return err
+	}
+	doc, err := opts.document(parser)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(opts.output, 0755); err != nil {
+		return err
+	}
+	if opts.format == FormatJSON || opts.format == FormatBoth {
+		if err := ioutil.WriteFile(opts.outputFile(".json"), doc, 0644); err != nil {
+			return err
+		}
+		opts.infof("wrote %s", opts.outputFile(".json"))
+	}
+	if opts.format == FormatYAML || opts.format == FormatBoth {
+		y, err := jsonToYAML(doc)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(opts.outputFile(".yaml"), y, 0644); err != nil {
+			return err
+		}
+		opts.infof("wrote %s", opts.outputFile(".yaml"))
+	}
+	if opts.outputGo != "" { // swaggo/swag serves swagger 2.0
+		b, err := opts.marshal(parser.swagger)
+		if err != nil {
+			return err
+		}
+		if err := writeDocsPackage(opts.outputGo, parser.swagger, b); err != nil {
+			return err
+		}
+		opts.infof("wrote %s", filepath.Join(opts.outputGo, "docs.go"))
+	}
+	return nil
+}
+
+func runValidate(args []string) error {
+	opts, _, err := parseFlags("validate", args, true)
+	if err != nil {
+		return err
+	}
+	parser, err := opts.parse()
+	if err != nil {
+		return err
+	}
+	parser.ValidateSpec()
+	if err := opts.report(parser, true); err != nil { // warnings fail the validation as well
+		return err
+	}
+	opts.infof("the spec is valid")
+	return nil
+}
+
+func runFmt(args []string) error {
+	opts, _, err := parseFlags("fmt", args, false)
+	if err != nil {
+		return err
+	}
+	parser := NewParser()
+	parser.Excludes = opts.excludes
+	for _, dir := range opts.searchDirs {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if rel, relErr := filepath.Rel(dir, path); relErr == nil && rel != "." && parser.excluded(rel) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.IsDir() || filepath.Ext(path) != ".go" || strings.Contains(path, "vendor") {
+				return nil
+			}
+			src, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			formatted, err := FormatComments(path, src)
+			if err != nil {
+				return err
+			}
+			if string(formatted) == string(src) {
+				return nil
+			}
+			opts.infof("formatted %s", path)
+			return ioutil.WriteFile(path, formatted, info.Mode())
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// liveDoc is registered with swaggo/swag by serve, it parses the sources every time the spec is read.
+type liveDoc struct {
+	opts *options
+	mu   sync.Mutex
+	last string
+}
+
+func (d *liveDoc) ReadDoc() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	doc, err := d.generate()
+	if err != nil {
+		log.Printf("serving the last spec: %v", err)
+		return d.last
+	}
+	d.last = doc
+	return doc
+}
+
+// generate parses the sources, printing their diagnostics.
+func (d *liveDoc) generate() (string, error) {
+	parser, err := d.opts.parse()
+	if err != nil {
+		return "", err
+	}
+	if err := d.opts.report(parser, false); err != nil {
+		return "", errors.New("the sources have errors")
+	}
+	b, err := d.opts.document(parser)
+	return string(b), err
+}
+
+func runServe(args []string) error {
+	opts, _, err := parseFlags("serve", args, true)
+	if err != nil {
+		return err
+	}
+	doc := &liveDoc{opts: opts}
+	if _, err := doc.generate(); err != nil {
+		return err
+	}
+	swag.Register(swag.Name, doc)
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.GET("/", func(c *gin.Context) {
+		c.Redirect(http.StatusFound, "/swagger/index.html")
+	})
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	opts.infof("serving the docs at http://localhost%s/swagger/index.html", opts.addr)
+	return router.Run(opts.addr)
+}
+
+func runDiff(args []string) error {
+	opts, flags, err := parseFlags("diff", args, true)
+	if err != nil {
+		return err
+	}
+	file := flags.Arg(0)
+	if file == "" {
+		file = opts.outputFile(".json")
+		if opts.format == FormatYAML {
+			file = opts.outputFile(".yaml")
+		}
+	}
+	written, err := readDocument(file)
+	if err != nil {
+		return err
+	}
+	parser, err := opts.parse()
+	if err != nil {
+		return err
+	}
+	if err := opts.report(parser, false); err != nil {
+		return err
+	}
+	doc, err := opts.document(parser)
+	if err != nil {
+		return err
+	}
+	var generated interface{}
+	if err := json.Unmarshal(doc, &generated); err != nil {
+		return err
+	}
+	differences := diffDocuments("", written, generated)
+	for _, difference := range differences {
+		fmt.Println(difference)
+	}
+	if len(differences) > 0 {
+		return errFailed
+	}
+	opts.infof("%s is up to date", file)
+	return nil
+}
+
+// readDocument reads a JSON or YAML spec as JSON values.
+func readDocument(file string) (interface{}, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if ext := filepath.Ext(file); ext == ".yaml" || ext == ".yml" {
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return nil, err
+		}
+		return jsonValue(doc), nil
+	}
+	err = json.Unmarshal(b, &doc)
+	return doc, err
+}
+
+// jsonValue converts a YAML value to the values encoding/json decodes.
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{})
+		for key, item := range v {
+			object[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return object
+	case []interface{}:
+		for i, item := range v {
+			v[i] = jsonValue(item)
+		}
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	}
+	return value
+}
+
+// diffDocuments lists the JSON pointers added (+), removed (-) and changed (~) from old to new.
+func diffDocuments(pointer string, old, new interface{}) []string {
+	oldObject, oldOk := old.(map[string]interface{})
+	newObject, newOk := new.(map[string]interface{})
+	if oldOk && newOk {
+		keys := make(map[string]bool)
+		for key := range oldObject {
+			keys[key] = true
+		}
+		for key := range newObject {
+			keys[key] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for key := range keys {
+			sorted = append(sorted, key)
+		}
+		sort.Strings(sorted)
+		var differences []string
+		for _, key := range sorted {
+			child := pointer + "/" + strings.Replace(strings.Replace(key, "~", "~0", -1), "/", "~1", -1)
+			oldValue, inOld := oldObject[key]
+			newValue, inNew := newObject[key]
+			switch {
+			case !inOld:
+				differences = append(differences, "+ "+child)
+			case !inNew:
+				differences = append(differences, "- "+child)
+			default:
+				differences = append(differences, diffDocuments(child, oldValue, newValue)...)
+			}
+		}
+		return differences
+	}
+	if reflect.DeepEqual(old, new) {
+		return nil
+	}
+	oldJSON, _ := json.Marshal(old)
+	newJSON, _ := json.Marshal(new)
+	if pointer == "" {
+		pointer = "/"
+	}
+	return []string{fmt.Sprintf("~ %s: %s -> %s", pointer, oldJSON, newJSON)}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"unicode"
+)
+
+// structuredAttributes are the annotations whose values are split on whitespace, their runs of
+// whitespace outside of quotes are collapsed by fmt.
+var structuredAttributes = map[string]bool{
+	"@param":    true,
+	"@success":  true,
+	"@failure":  true,
+	"@router":   true,
+	"@header":   true,
+	"@example":  true,
+	"@security": true,
+	"@accept":   true,
+	"@produce":  true,
+}
+
+// FormatComments aligns the values of the @annotations of every // comment group of the Go source src
+// and normalizes their spacing. The source is returned unchanged when there is nothing to format.
+func FormatComments(filename string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	for _, group := range file.Comments {
+		width := 0
+		for _, comment := range group.List {
+			if attribute, value, ok := splitAnnotation(comment.Text); ok && value != "" && len(attribute) > width {
+				width = len(attribute)
+			}
+		}
+		for _, comment := range group.List {
+			attribute, value, ok := splitAnnotation(comment.Text)
+			if !ok {
+				continue
+			}
+			text := "// " + attribute
+			if value != "" {
+				if structuredAttributes[strings.ToLower(attribute)] {
+					value = collapseSpaces(value)
+				}
+				text += strings.Repeat(" ", width-len(attribute)+1) + value
+			}
+			if text != comment.Text {
+				start := fset.Position(comment.Pos()).Offset
+				edits = append(edits, edit{start, start + len(comment.Text), text})
+			}
+		}
+	}
+	if len(edits) == 0 {
+		return src, nil
+	}
+	var formatted []byte
+	last := 0
+	for _, e := range edits {
+		formatted = append(formatted, src[last:e.start]...)
+		formatted = append(formatted, e.text...)
+		last = e.end
+	}
+	return append(formatted, src[last:]...), nil
+}
+
+// splitAnnotation splits a // comment like "// @Param  id path int true" into its attribute and value.
+func splitAnnotation(comment string) (attribute, value string, ok bool) {
+	if !strings.HasPrefix(comment, "//") {
+		return "", "", false
+	}
+	line := strings.TrimSpace(comment[2:])
+	if !strings.HasPrefix(line, "@") {
+		return "", "", false
+	}
+	end := strings.IndexFunc(line, unicode.IsSpace)
+	if end < 0 {
+		return line, "", true
+	}
+	return line[:end], strings.TrimSpace(line[end:]), true
+}
+
+// collapseSpaces replaces every run of whitespace outside of double quotes by a single space.
+func collapseSpaces(value string) string {
+	var b strings.Builder
+	quoted, space := false, false
+	for _, r := range value {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case !quoted && unicode.IsSpace(r):
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
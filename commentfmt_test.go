@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFormatCommentsRoundTrip formats a copy of the example and checks that its spec does not change.
+func TestFormatCommentsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	var formatted int
+	err := filepath.Walk("example", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".go" {
+			return err
+		}
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		out, err := FormatComments(path, src)
+		if err != nil {
+			return err
+		}
+		if string(out) != string(src) {
+			formatted++
+		}
+		target := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, out, 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if formatted == 0 {
+		t.Fatal("the example has no annotation to format, the round trip checks nothing")
+	}
+
+	specOf := func(dir string) string {
+		parser := NewParser()
+		parser.ParseApi(dir, "main.go")
+		if len(parser.Diagnostics) > 0 {
+			t.Fatalf("%s: %v", dir, parser.Diagnostics)
+		}
+		b, err := json.Marshal(parser.swagger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	example, err := filepath.Abs("example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before, after := specOf(example), specOf(filepath.Join(dir, "example")); before != after {
+		t.Errorf("formatting changed the spec\nbefore: %s\nafter:  %s", before, after)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Severity tells whether a diagnostic stops the spec from being written.
+type Severity string
+
+const (
+	// SeverityError reports a problem that leaves the spec incomplete or wrong.
+	SeverityError Severity = "error"
+	// SeverityWarning reports a problem the spec is written despite of.
+	SeverityWarning Severity = "warning"
+)
+
+// The codes of the diagnostics, for editors and scripts to tell them apart.
+const (
+	CodeRead            = "read"
+	CodeSyntax          = "syntax"
+	CodeGeneralInfo     = "general-info"
+	CodeSecurity        = "security"
+	CodeComment         = "comment"
+	CodePathParam       = "path-param"
+	CodeRouteConflict   = "route-conflict"
+	CodeUnsupportedType = "unsupported-type"
+	CodeSpec            = "spec"
+)
+
+// Diagnostic is a problem found while parsing, at the position of the source it comes from.
+type Diagnostic struct {
+	Pos      token.Position `json:"-"`
+	Severity Severity       `json:"severity"`
+	Code     string         `json:"code"`
+	Message  string         `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	if d.Pos.Filename == "" { // a problem of the spec, not of a source
+		return fmt.Sprintf("%s: %s [%s]", d.Severity, d.Message, d.Code)
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", d.Pos, d.Severity, d.Message, d.Code)
+}
+
+// MarshalJSON flattens the position into file, line and column fields.
+func (d Diagnostic) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		File     string   `json:"file"`
+		Line     int      `json:"line,omitempty"`
+		Column   int      `json:"column,omitempty"`
+		Severity Severity `json:"severity"`
+		Code     string   `json:"code"`
+		Message  string   `json:"message"`
+	}{d.Pos.Filename, d.Pos.Line, d.Pos.Column, d.Severity, d.Code, d.Message})
+}
+
+// report records a diagnostic at pos, a position of the shared file set.
+func (p *Parser) report(pos token.Pos, severity Severity, code, format string, args ...interface{}) {
+	p.reportAt(p.fileSet.Position(pos), severity, code, format, args...)
+}
+
+// reportAt records a diagnostic at a position that may not be part of the file set, like a file name.
+func (p *Parser) reportAt(position token.Position, severity Severity, code, format string, args ...interface{}) {
+	p.Diagnostics = append(p.Diagnostics, Diagnostic{
+		Pos:      position,
+		Severity: severity,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// reportParseError records the syntax errors of a Go file, or err itself when it is not a syntax error.
+func (p *Parser) reportParseError(filename string, err error) {
+	if list, ok := err.(scanner.ErrorList); ok {
+		for _, e := range list {
+			p.reportAt(e.Pos, SeverityError, CodeSyntax, "%s", e.Msg)
+		}
+		return
+	}
+	p.reportAt(token.Position{Filename: filename}, SeverityError, CodeSyntax, "%v", err)
+}
+
+// HasErrors reports whether a diagnostic is an error.
+func (p *Parser) HasErrors() bool {
+	for _, d := range p.Diagnostics {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteDiagnostics writes diagnostics to w like a compiler, the file names relative to dir when they
+// are below it, followed by the count of errors and warnings.
+func WriteDiagnostics(w io.Writer, diagnostics []Diagnostic, dir string) {
+	errs, warnings := 0, 0
+	for _, d := range diagnostics {
+		if rel, err := filepath.Rel(dir, d.Pos.Filename); err == nil && !strings.HasPrefix(rel, "..") {
+			d.Pos.Filename = rel
+		}
+		fmt.Fprintln(w, d)
+		if d.Severity == SeverityError {
+			errs++
+		} else {
+			warnings++
+		}
+	}
+	if len(diagnostics) > 0 {
+		fmt.Fprintf(w, "%d error(s), %d warning(s)\n", errs, warnings)
+	}
+}
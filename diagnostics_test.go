@@ -0,0 +1,109 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string // the diagnostic, its file name relative to the fixture
+	}{
+		{
+			name: "body param of an invalid definition name",
+			src: `package main
+
+func main() {}
+
+// @Param x body @foo%zz true "d"
+// @Router /x [post]
+func handle() {}
+`,
+			want: `main.go:5:1: error: Can not parse param comment "x body @foo%zz true "d"": invalid definition name "foo%zz"`,
+		},
+		{
+			name: "envelope property of an invalid definition name",
+			src: `package main
+
+func main() {}
+
+// @Success 200 {object} Envelope{data=foo%zz} "ok"
+// @Router /x [get]
+func handle() {}
+`,
+			want: `main.go:5:1: error: Can not parse response comment "200 {object} Envelope{data=foo%zz} "ok"": invalid definition name "foo%zz"`,
+		},
+		{
+			name: "malformed response",
+			src: `package main
+
+func main() {}
+
+// @Failure 400 {object}
+// @Router /x [get]
+func handle() {}
+`,
+			want: `main.go:5:1: error: Can not parse response comment "400 {object}". [comment]`,
+		},
+		{
+			name: "invalid @def name",
+			src: `package main
+
+func main() {}
+
+// @def Foo%zz
+type Foo struct{}
+`,
+			want: `main.go:5:1: error: invalid definition name "Foo%zz"`,
+		},
+		{
+			name: "invalid @envelope name",
+			src: `package main
+
+// @envelope Foo%zz data
+func main() {}
+`,
+			want: `main.go:3:1: error: invalid definition name "Foo%zz"`,
+		},
+		{
+			name: "incomplete security definition",
+			src: `package main
+
+// @title t
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+func main() {}
+`,
+			want: `main.go:4:1: error: apiKey security definition ApiKeyAuth needs @name and @in header or query [security]`,
+		},
+		{
+			name: "global requirement of an undeclared scheme",
+			src: `package main
+
+// @title t
+// @security OAuth2[read]
+func main() {}
+`,
+			want: `main.go:4:1: error: unknown security definition OAuth2 [security]`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parser := parseFixture(t, map[string]string{"main.go": test.src})
+			var got []string
+			for _, d := range parser.Diagnostics {
+				d.Pos.Filename = filepath.Base(d.Pos.Filename)
+				got = append(got, d.String())
+			}
+			if len(got) == 1 && strings.Contains(got[0], "\n") {
+				t.Errorf("diagnostic %q is not a single line", got[0])
+			}
+			if len(got) != 1 || !strings.HasPrefix(got[0], test.want) {
+				t.Errorf("got diagnostics %q, want one starting with %q", got, test.want)
+			}
+		})
+	}
+}
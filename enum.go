@@ -0,0 +1,193 @@
+package main
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// enumValue is a typed constant declared for a named type.
+type enumValue struct {
+	Name        string
+	Value       interface{}
+	Description string
+}
+
+// parseConsts collects the typed constants of file as enum values of their named type.
+// Implicitly repeated specs of iota blocks reuse the type and expression of the previous spec.
+func (p *Parser) parseConsts(file *ast.File) {
+	pkgName := file.Name.Name
+	for _, astDeclaration := range file.Decls {
+		genDecl, ok := astDeclaration.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.CONST {
+			continue
+		}
+		var typeExpr ast.Expr
+		var values []ast.Expr
+		for iota, astSpec := range genDecl.Specs {
+			valueSpec, ok := astSpec.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+			if valueSpec.Type != nil || len(valueSpec.Values) > 0 {
+				typeExpr = valueSpec.Type
+				values = valueSpec.Values
+			}
+			for i, name := range valueSpec.Names {
+				if i >= len(values) {
+					break
+				}
+				value := p.evalConst(pkgName, values[i], int64(iota))
+				p.constants[pkgName+"."+name.Name] = value
+				typeIdent, ok := typeExpr.(*ast.Ident)
+				if !ok || name.Name == "_" || value.Kind() == constant.Unknown {
+					continue
+				}
+				typeName := pkgName + "." + typeIdent.Name
+				p.enums[typeName] = append(p.enums[typeName], enumValue{
+					Name:        name.Name,
+					Value:       constantValue(value),
+					Description: constDescription(valueSpec),
+				})
+			}
+		}
+	}
+}
+
+// evalConst evaluates a constant expression of package pkgName, it returns an unknown value if the
+// expression uses anything but literals, iota, conversions and previously declared constants.
+func (p *Parser) evalConst(pkgName string, expr ast.Expr, iota int64) constant.Value {
+	switch expr := expr.(type) {
+	case *ast.BasicLit:
+		return constant.MakeFromLiteral(expr.Value, expr.Kind, 0)
+	case *ast.Ident:
+		switch expr.Name {
+		case "iota":
+			return constant.MakeInt64(iota)
+		case "true", "false":
+			return constant.MakeBool(expr.Name == "true")
+		}
+		if value, ok := p.constants[pkgName+"."+expr.Name]; ok {
+			return value
+		}
+	case *ast.ParenExpr:
+		return p.evalConst(pkgName, expr.X, iota)
+	case *ast.CallExpr: // conversions like Status("active"), builtins like len("abc") are unknown
+		if len(expr.Args) == 1 && p.isTypeName(pkgName, expr.Fun) {
+			return p.evalConst(pkgName, expr.Args[0], iota)
+		}
+	case *ast.UnaryExpr:
+		x := p.evalConst(pkgName, expr.X, iota)
+		if x.Kind() != constant.Unknown {
+			return constant.UnaryOp(expr.Op, x, 0)
+		}
+	case *ast.BinaryExpr:
+		x := p.evalConst(pkgName, expr.X, iota)
+		y := p.evalConst(pkgName, expr.Y, iota)
+		if x.Kind() == constant.Unknown || y.Kind() == constant.Unknown {
+			break
+		}
+		switch expr.Op {
+		case token.SHL, token.SHR: // untyped float operands like 1.0 << iota must be integers
+			x, y = constant.ToInt(x), constant.ToInt(y)
+			if x.Kind() != constant.Int || y.Kind() != constant.Int {
+				break
+			}
+			if shift, ok := constant.Uint64Val(y); ok {
+				return constant.Shift(x, expr.Op, uint(shift))
+			}
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+			return constant.MakeBool(constant.Compare(x, expr.Op, y))
+		case token.QUO:
+			if x.Kind() == constant.Int && y.Kind() == constant.Int {
+				return constant.BinaryOp(x, token.QUO_ASSIGN, y) // integer division
+			}
+			return constant.BinaryOp(x, expr.Op, y)
+		default:
+			return constant.BinaryOp(x, expr.Op, y)
+		}
+	}
+	return constant.MakeUnknown()
+}
+
+// isTypeName reports whether expr, written in package pkgName, names a builtin, declared or mapped type.
+func (p *Parser) isTypeName(pkgName string, expr ast.Expr) bool {
+	switch expr := expr.(type) {
+	case *ast.ParenExpr:
+		return p.isTypeName(pkgName, expr.X)
+	case *ast.Ident:
+		if _, ok := basicTypes[expr.Name]; ok {
+			return true
+		}
+		_, ok := p.TypeDefinitions[pkgName][expr.Name]
+		return ok
+	case *ast.SelectorExpr:
+		if pkg, ok := expr.X.(*ast.Ident); ok {
+			if _, ok := p.TypeMappings[pkg.Name+"."+expr.Sel.Name]; ok {
+				return true
+			}
+			_, ok := p.TypeDefinitions[pkg.Name][expr.Sel.Name]
+			return ok
+		}
+	}
+	return false
+}
+
+// constantValue converts a constant to the Go value used in the swagger document.
+func constantValue(value constant.Value) interface{} {
+	switch value.Kind() {
+	case constant.String:
+		return constant.StringVal(value)
+	case constant.Bool:
+		return constant.BoolVal(value)
+	case constant.Int:
+		if i, ok := constant.Int64Val(value); ok {
+			return i
+		}
+		if u, ok := constant.Uint64Val(value); ok {
+			return u
+		}
+	}
+	f, _ := constant.Float64Val(value)
+	return f
+}
+
+// constDescription returns the doc comment, or else the line comment, of a constant.
+func constDescription(valueSpec *ast.ValueSpec) string {
+	if valueSpec.Doc != nil {
+		return strings.TrimSpace(valueSpec.Doc.Text())
+	}
+	if valueSpec.Comment != nil {
+		return strings.TrimSpace(valueSpec.Comment.Text())
+	}
+	return ""
+}
+
+// withEnum adds the constants declared for the named type to schema, their doc comments become x-enum-descriptions.
+func (p *Parser) withEnum(schema spec.Schema, typeName string) spec.Schema {
+	values, ok := p.enums[typeName]
+	if !ok {
+		return schema
+	}
+	var descriptions []interface{}
+	hasDescription := false
+	schema.Enum = nil
+	for _, value := range values {
+		schema.Enum = append(schema.Enum, value.Value)
+		descriptions = append(descriptions, value.Description)
+		hasDescription = hasDescription || value.Description != ""
+	}
+	if hasDescription {
+		extensions := spec.Extensions{"x-enum-descriptions": descriptions}
+		for key, value := range schema.Extensions {
+			if key != "x-enum-descriptions" {
+				extensions[key] = value
+			}
+		}
+		schema.Extensions = extensions
+	}
+	return schema
+}
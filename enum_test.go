@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseEnums(t *testing.T) {
+	tests := []struct {
+		name   string
+		consts string
+		enum   string
+	}{
+		{
+			name: "iota block",
+			consts: `const (
+	Small Size = iota
+	Medium
+	Large
+)`,
+			enum: `[0,1,2]`,
+		},
+		{
+			name: "conversions and expressions",
+			consts: `const (
+	One Size = Size(1)
+	Ten Size = (Size)(One * 10)
+	Half Size = Ten / 3
+)`,
+			enum: `[1,10,3]`,
+		},
+		{
+			name: "shifts of untyped floats",
+			consts: `const (
+	Tiny Size = 1.0 << iota
+	Huge
+)`,
+			enum: `[1,2]`,
+		},
+		{
+			name: "builtin calls are not conversions",
+			consts: `const (
+	One Size = 1
+	N   Size = len("abc")
+)`,
+			enum: `[1]`,
+		},
+		{
+			name:   "conversion to a type of a later file",
+			consts: `const Two Size = Size(2)`,
+			enum:   `[2]`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parser := parseFixture(t, map[string]string{
+				"main.go": `package main
+
+func main() {}
+
+// @def Model
+type Model struct {
+	Size Size ` + "`json:\"size\"`" + `
+}
+
+` + test.consts + "\n",
+				"types.go": "package main\n\ntype Size int\n",
+			})
+			if len(parser.Diagnostics) > 0 {
+				t.Fatalf("unexpected diagnostics: %v", parser.Diagnostics)
+			}
+			b, err := json.Marshal(parser.swagger.Definitions["Model"].Properties["size"].Enum)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != test.enum {
+				t.Errorf("got enum %s, want %s", b, test.enum)
+			}
+		})
+	}
+}
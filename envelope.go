@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// compositeSchema returns the schema of a response type overriding properties of a definition, like
+// @Envelope{data=[]@Pets}: the definition and an object of the overridden properties, combined with allOf.
+// Property types are written like response types and can be composite themselves.
+func (operation *Operation) compositeSchema(dataType string) (spec.Schema, error) {
+	open := strings.Index(dataType, "{")
+	if open < 0 {
+		return operation.typeSchema(dataType)
+	}
+	if !strings.HasSuffix(dataType, "}") {
+		return spec.Schema{}, fmt.Errorf("missing } in response type \"%s\"", dataType)
+	}
+	base, _ := operation.refTypeName(dataType[:open])
+	if _, err := definitionRef(base); err != nil {
+		return spec.Schema{}, err
+	}
+	properties := make(map[string]spec.Schema)
+	for _, field := range splitFields(dataType[open+1 : len(dataType)-1]) {
+		nameType := strings.SplitN(field, "=", 2)
+		if len(nameType) != 2 {
+			return spec.Schema{}, fmt.Errorf("property \"%s\" of response type \"%s\" is not name=type", field, dataType)
+		}
+		property, err := operation.compositeSchema(strings.TrimSpace(nameType[1]))
+		if err != nil {
+			return spec.Schema{}, err
+		}
+		properties[strings.TrimSpace(nameType[0])] = property
+	}
+	return envelopeSchema(base, properties), nil
+}
+
+// typeSchema returns the schema of a type written in a comment: a swagger or Go primitive type, []T for
+// an array, or a model that is referenced.
+func (operation *Operation) typeSchema(dataType string) (spec.Schema, error) {
+	if strings.HasPrefix(dataType, "[]") {
+		items, err := operation.compositeSchema(dataType[len("[]"):])
+		if err != nil {
+			return spec.Schema{}, err
+		}
+		return spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Type:  []string{"array"},
+				Items: &spec.SchemaOrArray{Schema: &items},
+			},
+		}, nil
+	}
+	switch dataType {
+	case "string", "number", "integer", "boolean", "object":
+		return spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{dataType}}}, nil
+	}
+	if schema, ok := basicSchema(dataType); ok {
+		return schema, nil
+	}
+	refTypeName, _ := operation.refTypeName(dataType)
+	ref, err := definitionRef(refTypeName)
+	if err != nil {
+		return spec.Schema{}, err
+	}
+	return spec.Schema{SchemaProps: spec.SchemaProps{Ref: ref}}, nil
+}
+
+// splitFields splits the properties of a composite type at the commas outside of nested braces.
+func splitFields(fields string) []string {
+	var result []string
+	depth, start := 0, 0
+	for i, c := range fields {
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				result = append(result, fields[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if strings.TrimSpace(fields[start:]) != "" {
+		result = append(result, fields[start:])
+	}
+	return result
+}
+
+// envelopeSchema combines the definition refTypeName with an object overriding some of its properties.
+func envelopeSchema(refTypeName string, properties map[string]spec.Schema) spec.Schema {
+	return spec.Schema{
+		SchemaProps: spec.SchemaProps{
+			AllOf: []spec.Schema{
+				refSchema(refTypeName),
+				{SchemaProps: spec.SchemaProps{Type: []string{"object"}, Properties: properties}},
+			},
+		},
+	}
+}
+
+// wrapEnvelope wraps the schemas of the 2xx responses in the property field of the envelope definition,
+// unless they already are or extend the envelope.
+func (operation *Operation) wrapEnvelope(envelope, field string) {
+	if operation.Responses == nil {
+		return
+	}
+	refTypeName, _ := operation.refTypeName(envelope)
+	envelopeRef := refSchema(refTypeName)
+	ref := envelopeRef.Ref.String()
+	for code, response := range operation.Responses.StatusCodeResponses {
+		if code < 200 || code > 299 || response.Schema == nil {
+			continue
+		}
+		schema := response.Schema
+		if schema.Items != nil && schema.Items.Schema != nil && isEnvelope(schema.Items.Schema, ref) {
+			continue
+		}
+		if isEnvelope(schema, ref) {
+			continue
+		}
+		wrapped := envelopeSchema(refTypeName, map[string]spec.Schema{field: *schema})
+		response.Schema = &wrapped
+		operation.Responses.StatusCodeResponses[code] = response
+	}
+}
+
+// isEnvelope reports whether schema references, or extends with allOf, the definition ref.
+func isEnvelope(schema *spec.Schema, ref string) bool {
+	return schema.Ref.String() == ref || (len(schema.AllOf) > 0 && schema.AllOf[0].Ref.String() == ref)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseCompositeResponseComment(t *testing.T) {
+	tests := []struct {
+		comment     string
+		schema      string
+		description string
+	}{
+		{
+			comment:     `200 {object} Envelope{data=Pets} "ok"`,
+			schema:      `{"allOf":[{"$ref":"#/definitions/Envelope"},{"type":"object","properties":{"data":{"$ref":"#/definitions/Pets"}}}]}`,
+			description: "ok",
+		},
+		{
+			comment:     `200 {object} Envelope{data=Pets} "returns {id}"`,
+			schema:      `{"allOf":[{"$ref":"#/definitions/Envelope"},{"type":"object","properties":{"data":{"$ref":"#/definitions/Pets"}}}]}`,
+			description: "returns {id}",
+		},
+		{
+			comment:     `200 {object} Envelope{data=Page{items=[]Pets}} "a {page}"`,
+			schema:      `{"allOf":[{"$ref":"#/definitions/Envelope"},{"type":"object","properties":{"data":{"allOf":[{"$ref":"#/definitions/Page"},{"type":"object","properties":{"items":{"type":"array","items":{"$ref":"#/definitions/Pets"}}}}]}}}]}`,
+			description: "a {page}",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.comment, func(t *testing.T) {
+			operation := NewOperation()
+			if err := operation.ParseResponseComment(test.comment); err != nil {
+				t.Fatal(err)
+			}
+			response := operation.Responses.StatusCodeResponses[200]
+			b, err := json.Marshal(response.Schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != test.schema {
+				t.Errorf("got schema %s, want %s", b, test.schema)
+			}
+			if response.Description != test.description {
+				t.Errorf("got description %q, want %q", response.Description, test.description)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/rookiejin/swagger/example/controller"
 )
 
 // @title GOLANG-GIN
@@ -13,5 +14,8 @@ import (
 // @tags contents 内容部分
 func main()  {
 	g := gin.Default()
-	g.POST("/v1/pet")
+	v1 := g.Group("/v1")
+	v1.GET("/pets", controller.GetPets)
+	v1.POST("/pets", controller.CreatePets)
+	g.Run()
 }
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"go/ast"
+	"go/constant"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ginImportPath is the import path of the gin framework whose router registrations are parsed.
+const ginImportPath = "github.com/gin-gonic/gin"
+
+// ginRouterTypes are the gin types that register routes.
+var ginRouterTypes = []string{"Engine", "RouterGroup", "IRouter", "IRoutes"}
+
+// ginAnyMethods are the methods registered by RouterGroup.Any that swagger can describe.
+var ginAnyMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodHead, http.MethodOptions, http.MethodDelete,
+}
+
+// route is a path and http method a handler is registered at.
+type route struct {
+	Method string
+	Path   string
+}
+
+// ginParamRegexp matches the :param and *wildcard segments of a gin path.
+var ginParamRegexp = regexp.MustCompile(`/[:*]([^/]+)`)
+
+// pathParamRegexp matches the {param} segments of a swagger path.
+var pathParamRegexp = regexp.MustCompile(`\{([^}/]+)\}`)
+
+// swaggerPath converts the :param and *wildcard segments of a gin path to {param}.
+func swaggerPath(ginPath string) string {
+	return ginParamRegexp.ReplaceAllString(ginPath, "/{$1}")
+}
+
+// routerScope stores the path prefix of the router variables of a function body.
+type routerScope map[string]string
+
+// ParseRoutes finds the gin router registrations of all files and links them to their handler functions.
+// Functions receiving a router are followed from their call sites, so the prefixes of Group calls
+// are known; those that are never called with a known router are parsed with an empty prefix, in source order.
+func (p *Parser) ParseRoutes() {
+	for _, file := range p.files {
+		for _, astDeclaration := range file.Decls {
+			if funcDecl, ok := astDeclaration.(*ast.FuncDecl); ok {
+				p.funcFiles[funcDecl] = file
+				if funcDecl.Recv == nil {
+					p.funcDecls[file.Name.Name+"."+funcDecl.Name.Name] = funcDecl
+				} else {
+					p.methodDecls[funcDecl.Name.Name] = append(p.methodDecls[funcDecl.Name.Name], funcDecl)
+				}
+			}
+		}
+	}
+
+	funcDecls := make([]*ast.FuncDecl, 0, len(p.funcFiles))
+	for funcDecl := range p.funcFiles {
+		if funcDecl.Body != nil {
+			funcDecls = append(funcDecls, funcDecl)
+		}
+	}
+	sort.Slice(funcDecls, func(i, j int) bool { // the map order would change which call site is followed first
+		a, b := p.fileSet.Position(funcDecls[i].Pos()), p.fileSet.Position(funcDecls[j].Pos())
+		return a.Filename < b.Filename || (a.Filename == b.Filename && a.Offset < b.Offset)
+	})
+
+	visited := make(map[*ast.FuncDecl]bool)
+	called := make(map[*ast.FuncDecl]bool)
+	var routerFuncs []*ast.FuncDecl
+	for _, funcDecl := range funcDecls {
+		file := p.funcFiles[funcDecl]
+		ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+			if callExpr, ok := node.(*ast.CallExpr); ok {
+				if callee := p.findFuncDecl(file, callExpr.Fun); callee != nil && callee != funcDecl {
+					called[callee] = true
+				}
+			}
+			return true
+		})
+		if len(p.routerParams(file, funcDecl)) > 0 {
+			routerFuncs = append(routerFuncs, funcDecl)
+			continue
+		}
+		p.parseRouterBody(file, funcDecl.Body, routerScope{}, visited, 0)
+	}
+	// router functions nobody calls are the entry points, those they call are followed with their
+	// prefix; the router functions still not reached are called from outside the scanned files
+	for _, entryPoints := range []bool{true, false} {
+		for _, funcDecl := range routerFuncs {
+			if visited[funcDecl] || called[funcDecl] == entryPoints {
+				continue
+			}
+			visited[funcDecl] = true
+			file := p.funcFiles[funcDecl]
+			scope := routerScope{}
+			for _, name := range p.routerParams(file, funcDecl) {
+				scope[name] = ""
+			}
+			p.parseRouterBody(file, funcDecl.Body, scope, visited, 0)
+		}
+	}
+}
+
+// routerParams returns the names of the parameters of funcDecl that are gin routers.
+func (p *Parser) routerParams(file *ast.File, funcDecl *ast.FuncDecl) []string {
+	var names []string
+	for _, field := range funcDecl.Type.Params.List {
+		if isGinRouterType(file, field.Type) {
+			for _, name := range field.Names {
+				names = append(names, name.Name)
+			}
+		}
+	}
+	return names
+}
+
+// isGinRouterType reports whether typeExpr is one of ginRouterTypes, optionally a pointer.
+func isGinRouterType(file *ast.File, typeExpr ast.Expr) bool {
+	if starExpr, ok := typeExpr.(*ast.StarExpr); ok {
+		typeExpr = starExpr.X
+	}
+	selectorExpr, ok := typeExpr.(*ast.SelectorExpr)
+	if !ok || !isGinPackage(file, selectorExpr.X) {
+		return false
+	}
+	for _, typeName := range ginRouterTypes {
+		if selectorExpr.Sel.Name == typeName {
+			return true
+		}
+	}
+	return false
+}
+
+// isGinPackage reports whether expr is the local name of the gin import in file.
+func isGinPackage(file *ast.File, expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	for _, importSpec := range file.Imports {
+		if importPath, _ := strconv.Unquote(importSpec.Path.Value); importPath == ginImportPath {
+			if importSpec.Name != nil {
+				return importSpec.Name.Name == ident.Name
+			}
+			return ident.Name == "gin"
+		}
+	}
+	return false
+}
+
+// parseRouterBody records the routes registered in body, where scope holds the known router variables.
+// Routers passed to functions of the scanned packages are followed up to a few calls deep.
+func (p *Parser) parseRouterBody(file *ast.File, body *ast.BlockStmt, scope routerScope, visited map[*ast.FuncDecl]bool, depth int) {
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range node.Lhs {
+				ident, ok := lhs.(*ast.Ident)
+				if !ok || len(node.Rhs) != len(node.Lhs) {
+					continue
+				}
+				if prefix, ok := p.routerPrefix(file, node.Rhs[i], scope); ok {
+					scope[ident.Name] = prefix
+				}
+			}
+		case *ast.ValueSpec:
+			for i, name := range node.Names {
+				if i < len(node.Values) {
+					if prefix, ok := p.routerPrefix(file, node.Values[i], scope); ok {
+						scope[name.Name] = prefix
+					}
+				} else if node.Type != nil && isGinRouterType(file, node.Type) {
+					scope[name.Name] = ""
+				}
+			}
+		case *ast.CallExpr:
+			p.parseRouterCall(file, node, scope, visited, depth)
+		}
+		return true
+	})
+}
+
+// parseRouterCall records a route registration like `v1.GET("/pets", GetPets)` or follows a call
+// like `controller.Register(v1)` into the called function.
+func (p *Parser) parseRouterCall(file *ast.File, callExpr *ast.CallExpr, scope routerScope, visited map[*ast.FuncDecl]bool, depth int) {
+	if selectorExpr, ok := callExpr.Fun.(*ast.SelectorExpr); ok {
+		if prefix, ok := p.routerPrefix(file, selectorExpr.X, scope); ok {
+			method := strings.ToUpper(selectorExpr.Sel.Name)
+			args := callExpr.Args
+			var methods []string
+			switch method {
+			case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete,
+				http.MethodPatch, http.MethodHead, http.MethodOptions:
+				methods = []string{method}
+			case "ANY":
+				methods = ginAnyMethods
+			case "HANDLE":
+				if len(args) == 0 {
+					return
+				}
+				handleMethod, ok := p.stringValue(file, args[0])
+				if !ok {
+					return
+				}
+				methods = []string{strings.ToUpper(handleMethod)}
+				args = args[1:]
+			default:
+				return
+			}
+			if len(args) < 2 { // a path and at least one handler
+				return
+			}
+			relativePath, ok := p.stringValue(file, args[0])
+			if !ok {
+				return
+			}
+			handler := p.findFuncDecl(file, args[len(args)-1])
+			if handler == nil {
+				return
+			}
+			for _, method := range methods {
+				p.addRoute(handler, method, joinPaths(prefix, relativePath))
+			}
+			return
+		}
+	}
+
+	if depth >= 8 {
+		return
+	}
+	funcDecl := p.findFuncDecl(file, callExpr.Fun)
+	if funcDecl == nil || funcDecl.Body == nil {
+		return
+	}
+	calleeFile := p.funcFiles[funcDecl]
+	calleeScope := routerScope{}
+	i := 0
+	for _, field := range funcDecl.Type.Params.List {
+		for _, name := range field.Names {
+			if i < len(callExpr.Args) && isGinRouterType(calleeFile, field.Type) {
+				if prefix, ok := p.routerPrefix(file, callExpr.Args[i], scope); ok {
+					calleeScope[name.Name] = prefix
+				}
+			}
+			i++
+		}
+	}
+	if len(calleeScope) == 0 {
+		return
+	}
+	visited[funcDecl] = true
+	p.parseRouterBody(calleeFile, funcDecl.Body, calleeScope, visited, depth+1)
+}
+
+// routerPrefix returns the path prefix of a router expression: gin.New(), gin.Default(), a known
+// router variable or the result of its Group and Use calls.
+func (p *Parser) routerPrefix(file *ast.File, expr ast.Expr, scope routerScope) (string, bool) {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		prefix, ok := scope[expr.Name]
+		return prefix, ok
+	case *ast.ParenExpr:
+		return p.routerPrefix(file, expr.X, scope)
+	case *ast.StarExpr:
+		return p.routerPrefix(file, expr.X, scope)
+	case *ast.UnaryExpr:
+		return p.routerPrefix(file, expr.X, scope)
+	case *ast.CallExpr:
+		selectorExpr, ok := expr.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return "", false
+		}
+		if isGinPackage(file, selectorExpr.X) {
+			return "", selectorExpr.Sel.Name == "New" || selectorExpr.Sel.Name == "Default"
+		}
+		prefix, ok := p.routerPrefix(file, selectorExpr.X, scope)
+		if !ok {
+			return "", false
+		}
+		switch selectorExpr.Sel.Name {
+		case "Use":
+			return prefix, true
+		case "Group":
+			if len(expr.Args) == 0 {
+				return "", false
+			}
+			relativePath, ok := p.stringValue(file, expr.Args[0])
+			return joinPaths(prefix, relativePath), ok
+		}
+	}
+	return "", false
+}
+
+// findFuncDecl returns the function declaration of a handler or called function expression:
+// a function of the same package, an imported package function, a method value resolved by its
+// unique name, or the function returning the handler when expr is a call. It returns nil otherwise.
+func (p *Parser) findFuncDecl(file *ast.File, expr ast.Expr) *ast.FuncDecl {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return p.funcDecls[file.Name.Name+"."+expr.Name]
+	case *ast.SelectorExpr:
+		if pkg, ok := expr.X.(*ast.Ident); ok && isImported(file, pkg.Name) {
+			return p.funcDecls[importedPackage(file, pkg.Name)+"."+expr.Sel.Name]
+		}
+		if methods := p.methodDecls[expr.Sel.Name]; len(methods) == 1 {
+			return methods[0]
+		}
+	case *ast.CallExpr: // handler factories like GetPets(db)
+		return p.findFuncDecl(file, expr.Fun)
+	case *ast.ParenExpr:
+		return p.findFuncDecl(file, expr.X)
+	}
+	return nil
+}
+
+// isImported reports whether localName names an import of file.
+func isImported(file *ast.File, localName string) bool {
+	for _, importSpec := range file.Imports {
+		if importSpec.Name != nil {
+			if importSpec.Name.Name == localName {
+				return true
+			}
+			continue
+		}
+		importPath, _ := strconv.Unquote(importSpec.Path.Value)
+		if strings.SplitN(path.Base(importPath), ".", 2)[0] == localName {
+			return true
+		}
+	}
+	return false
+}
+
+// stringValue returns the value of a string literal or string constant.
+func (p *Parser) stringValue(file *ast.File, expr ast.Expr) (string, bool) {
+	value := p.evalConst(file.Name.Name, expr, 0)
+	if selectorExpr, ok := expr.(*ast.SelectorExpr); ok {
+		if pkg, ok := selectorExpr.X.(*ast.Ident); ok {
+			if v, ok := p.constants[importedPackage(file, pkg.Name)+"."+selectorExpr.Sel.Name]; ok {
+				value = v
+			}
+		}
+	}
+	if value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(value), true
+}
+
+// addRoute records that handler is registered at method and routePath, relative to the @BasePath.
+func (p *Parser) addRoute(handler *ast.FuncDecl, method, routePath string) {
+	if basePath := strings.TrimSuffix(p.swagger.BasePath, "/"); basePath != "" && strings.HasPrefix(routePath, basePath+"/") {
+		routePath = strings.TrimPrefix(routePath, basePath)
+	}
+	r := route{Method: method, Path: swaggerPath(routePath)}
+	for _, existing := range p.routes[handler] {
+		if existing == r { // the same registration reached through another call path
+			return
+		}
+	}
+	p.routes[handler] = append(p.routes[handler], r)
+	p.logf("route %s %s to %s", method, routePath, handler.Name.Name)
+}
+
+// joinPaths joins a group prefix and a relative path the way gin does, keeping a trailing slash.
+func joinPaths(absolutePath, relativePath string) string {
+	if len(relativePath) == 0 {
+		return absolutePath
+	}
+	finalPath := path.Join(absolutePath, relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(finalPath, "/") {
+		return finalPath + "/"
+	}
+	return finalPath
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// parseFixture writes files, by their path relative to a temporary app, and parses the app from main.go.
+func parseFixture(t *testing.T, files map[string]string) *Parser {
+	t.Helper()
+	return parseFixtureWith(t, NewParser(), files)
+}
+
+// parseFixtureWith is parseFixture with a configured parser.
+func parseFixtureWith(t *testing.T, parser *Parser, files map[string]string) *Parser {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	parser.ParseApis([]string{dir}, "main.go")
+	return parser
+}
+
+// documentedRoutes returns the "METHOD path handler" of every documented operation, sorted.
+func documentedRoutes(p *Parser) []string {
+	var routes []string
+	for key, handler := range p.operationSources {
+		routes = append(routes, key+" "+handler.Name.Name)
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+const fixtureHandlers = `package handler
+
+import "github.com/gin-gonic/gin"
+
+func ListPets(c *gin.Context) {}
+
+func GetPet(c *gin.Context) {}
+
+func Ping(c *gin.Context) {}
+`
+
+func TestParseRoutes(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "nested Group prefixes",
+			files: map[string]string{
+				"main.go": `package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"example.com/app/handler"
+)
+
+func main() {
+	r := gin.New()
+	api := r.Group("/api")
+	v1 := api.Group("/v1")
+	v1.GET("/pets", handler.ListPets)
+	v1.Group("/pets").GET("/:id", handler.GetPet)
+	r.GET("/ping", handler.Ping)
+}
+`,
+				"handler/handler.go": fixtureHandlers,
+			},
+			want: []string{
+				"GET /api/v1/pets ListPets",
+				"GET /api/v1/pets/{id} GetPet",
+				"GET /ping Ping",
+			},
+		},
+		{
+			name: "router function called from several places",
+			files: map[string]string{
+				"main.go": `package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"example.com/app/handler"
+)
+
+func main() {
+	r := gin.New()
+	mountPets(r.Group("/v1"))
+	mountPets(r.Group("/v2"))
+}
+
+func mountPets(g *gin.RouterGroup) {
+	g.GET("/pets", handler.ListPets)
+}
+`,
+				"handler/handler.go": fixtureHandlers,
+			},
+			want: []string{
+				"GET /v1/pets ListPets",
+				"GET /v2/pets ListPets",
+			},
+		},
+		{
+			name: "router function only called by another router function",
+			files: map[string]string{
+				"main.go": `package main
+
+func main() {}
+`,
+				"lib/lib.go": `package lib
+
+import (
+	"github.com/gin-gonic/gin"
+	"example.com/app/handler"
+)
+
+func Mount(r gin.IRouter) {
+	mountPets(r.Group("/api"))
+}
+
+func mountPets(g gin.IRouter) {
+	g.GET("/pets", handler.ListPets)
+}
+`,
+				"handler/handler.go": fixtureHandlers,
+			},
+			want: []string{
+				"GET /api/pets ListPets",
+			},
+		},
+		{
+			name: "registration reached through two call paths",
+			files: map[string]string{
+				"main.go": `package main
+
+import "github.com/gin-gonic/gin"
+
+func main() {
+	r := gin.New()
+	setup(r)
+	setupAll(r)
+}
+
+func setupAll(r *gin.Engine) {
+	setup(r)
+}
+
+func setup(r *gin.Engine) {
+	r.GET("/x", handle)
+}
+
+func handle(c *gin.Context) {}
+`,
+			},
+			want: []string{
+				"GET /x handle",
+			},
+		},
+		{
+			name: "Handle and Any",
+			files: map[string]string{
+				"main.go": `package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"example.com/app/handler"
+)
+
+const petPath = "/pets/:id"
+
+func main() {
+	r := gin.Default()
+	r.Handle("DELETE", petPath, handler.GetPet)
+	r.Handle(http.MethodPut, "/pets", handler.ListPets)
+	r.Any("/ping", handler.Ping)
+}
+`,
+				"handler/handler.go": fixtureHandlers,
+			},
+			want: []string{
+				"DELETE /pets/{id} GetPet",
+				"DELETE /ping Ping",
+				"GET /ping Ping",
+				"HEAD /ping Ping",
+				"OPTIONS /ping Ping",
+				"PATCH /ping Ping",
+				"POST /ping Ping",
+				"PUT /ping Ping",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ { // the functions are found through maps, the result must not depend on their order
+				parser := parseFixture(t, test.files)
+				if len(parser.Diagnostics) > 0 {
+					t.Fatalf("unexpected diagnostics: %v", parser.Diagnostics)
+				}
+				if got := documentedRoutes(parser); !reflect.DeepEqual(got, test.want) {
+					t.Fatalf("run %d: got routes %q, want %q", i, got, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRouteParameters(t *testing.T) {
+	tests := []struct {
+		name   string
+		strict bool
+		src    string
+		path   string
+		params []string
+		err    string // the diagnostic, its file name relative to the fixture
+	}{
+		{
+			name: ":param and *wildcard segments",
+			src: `func main() {
+	r := gin.New()
+	r.GET("/files/:id/*filepath", handle)
+}
+
+func handle(c *gin.Context) {}
+`,
+			path:   "/files/{id}/{filepath}",
+			params: []string{"path filepath string true", "path id string true"},
+		},
+		{
+			name: "@Param of a path param is required",
+			src: `func main() {}
+
+// @Param id path int false "id"
+// @Router /pets/:id [get]
+func handle(c *gin.Context) {}
+`,
+			path:   "/pets/{id}",
+			params: []string{"path id integer true"},
+		},
+		{
+			name: "@Param not in the route",
+			src: `func main() {}
+
+// @Param name path string true "name"
+// @Router /pets/{id} [get]
+func handle(c *gin.Context) {}
+`,
+			err: `main.go:9:1: error: path param "name" is not part of the route /pets/{id} [path-param]`,
+		},
+		{
+			name:   "StrictPathParams",
+			strict: true,
+			src: `func main() {}
+
+// @Router /pets/{id} [get]
+func handle(c *gin.Context) {}
+`,
+			err: `main.go:8:1: error: route /pets/{id} has no @Param for its path param "id" [path-param]`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parser := NewParser()
+			parser.StrictPathParams = test.strict
+			parseFixtureWith(t, parser, map[string]string{
+				"main.go": "package main\n\nimport \"github.com/gin-gonic/gin\"\n\n" + test.src,
+			})
+			var diagnostics []string
+			for _, d := range parser.Diagnostics {
+				d.Pos.Filename = filepath.Base(d.Pos.Filename)
+				diagnostics = append(diagnostics, d.String())
+			}
+			if test.err != "" {
+				if len(diagnostics) != 1 || diagnostics[0] != test.err {
+					t.Errorf("got diagnostics %q, want %q", diagnostics, test.err)
+				}
+				if len(parser.operationSources) > 0 {
+					t.Errorf("got routes %q, want none", documentedRoutes(parser))
+				}
+				return
+			}
+			if len(diagnostics) > 0 {
+				t.Fatalf("unexpected diagnostics: %q", diagnostics)
+			}
+			item, ok := parser.swagger.Paths.Paths[test.path]
+			if !ok || item.Get == nil {
+				t.Fatalf("got routes %q, want GET %s", documentedRoutes(parser), test.path)
+			}
+			if got := describeParameters(item.Get.Parameters); !reflect.DeepEqual(got, test.params) {
+				t.Errorf("got parameters %q, want %q", got, test.params)
+			}
+		})
+	}
+}
+
+func TestRouteConflicts(t *testing.T) {
+	parser := parseFixture(t, map[string]string{"main.go": `package main
+
+import "github.com/gin-gonic/gin"
+
+func main() {}
+
+// @Router /pets [get]
+// @Router /v1/pets [get]
+func listPets(c *gin.Context) {}
+
+// @Router /pets [get]
+func listAnimals(c *gin.Context) {}
+`})
+	want := []string{"GET /pets listPets", "GET /v1/pets listPets"}
+	if got := documentedRoutes(parser); !reflect.DeepEqual(got, want) {
+		t.Errorf("got routes %q, want %q", got, want)
+	}
+	if len(parser.Diagnostics) != 1 {
+		t.Fatalf("got diagnostics %v, want a route conflict", parser.Diagnostics)
+	}
+	d := parser.Diagnostics[0]
+	first := filepath.Join(filepath.Dir(d.Pos.Filename), "main.go") + ":9:1"
+	message := "GET /pets is already documented by the handler at " + first + ", this handler is ignored"
+	if d.Pos.Line != 12 || d.Severity != SeverityWarning || d.Code != CodeRouteConflict || d.Message != message {
+		t.Errorf("got diagnostic %v, want a warning at line 12: %s", d, message)
+	}
+}
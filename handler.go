@@ -0,0 +1,619 @@
+package main
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/go-openapi/spec"
+)
+
+// ginParamAccessors maps the *gin.Context methods reading a request value to the parameter location.
+var ginParamAccessors = map[string]string{
+	"Query":            "query",
+	"DefaultQuery":     "query",
+	"GetQuery":         "query",
+	"QueryArray":       "query",
+	"GetQueryArray":    "query",
+	"Param":            "path",
+	"PostForm":         "formData",
+	"DefaultPostForm":  "formData",
+	"GetPostForm":      "formData",
+	"PostFormArray":    "formData",
+	"GetPostFormArray": "formData",
+	"FormFile":         "formData",
+	"GetHeader":        "header",
+	"Cookie":           "cookie",
+}
+
+// ginBodyBinders are the *gin.Context methods decoding the request body into their argument.
+var ginBodyBinders = map[string]bool{
+	"Bind":               true,
+	"BindJSON":           true,
+	"BindXML":            true,
+	"ShouldBind":         true,
+	"ShouldBindJSON":     true,
+	"ShouldBindXML":      true,
+	"ShouldBindBodyWith": true,
+}
+
+// ginStructBinders maps the *gin.Context methods binding request values into the fields of their argument
+// to the parameter location.
+var ginStructBinders = map[string]string{
+	"BindQuery":        "query",
+	"ShouldBindQuery":  "query",
+	"BindHeader":       "header",
+	"ShouldBindHeader": "header",
+	"BindUri":          "path",
+	"ShouldBindUri":    "path",
+}
+
+// ginBindingLocations maps the gin/binding engines binding request values into struct fields, when passed
+// to BindWith, to the parameter location.
+var ginBindingLocations = map[string]string{
+	"Query":         "query",
+	"Form":          "query",
+	"FormPost":      "formData",
+	"FormMultipart": "formData",
+	"Header":        "header",
+	"Uri":           "path",
+}
+
+// ginBindingBodies are the gin/binding engines that read the request body when passed to BindWith.
+var ginBindingBodies = map[string]bool{"JSON": true, "XML": true, "ProtoBuf": true, "MsgPack": true, "YAML": true}
+
+// typeRef is a type expression and the file it is written in.
+type typeRef struct {
+	file *ast.File
+	expr ast.Expr
+}
+
+// handlerScan holds the *gin.Context and the typed variables of a handler body being scanned.
+type handlerScan struct {
+	parser   *Parser
+	file     *ast.File
+	ctxNames map[string]bool
+	varTypes map[string]typeRef
+}
+
+// ginContextParams returns the names of the *gin.Context parameters of funcDecl.
+func ginContextParams(file *ast.File, funcDecl *ast.FuncDecl) map[string]bool {
+	names := make(map[string]bool)
+	for _, field := range funcDecl.Type.Params.List {
+		starExpr, ok := field.Type.(*ast.StarExpr)
+		if !ok {
+			continue
+		}
+		if selectorExpr, ok := starExpr.X.(*ast.SelectorExpr); ok && selectorExpr.Sel.Name == "Context" && isGinPackage(file, selectorExpr.X) {
+			for _, name := range field.Names {
+				names[name.Name] = true
+			}
+		}
+	}
+	return names
+}
+
+// newHandlerScan returns the scan of funcDecl with ctxNames bound to the *gin.Context. The types of
+// its parameters and of local variables initialized with literals, new(T) or function calls are known.
+func (p *Parser) newHandlerScan(file *ast.File, funcDecl *ast.FuncDecl, ctxNames map[string]bool) *handlerScan {
+	scan := &handlerScan{parser: p, file: file, ctxNames: ctxNames, varTypes: make(map[string]typeRef)}
+	for _, field := range funcDecl.Type.Params.List {
+		for _, name := range field.Names {
+			scan.varTypes[name.Name] = typeRef{file: file, expr: field.Type}
+		}
+	}
+	ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.AssignStmt:
+			if len(node.Rhs) == 1 && len(node.Lhs) > 1 { // pets, err := service.ListPets()
+				for i, lhs := range node.Lhs {
+					if ident, ok := lhs.(*ast.Ident); ok {
+						if ref, ok := scan.resultType(node.Rhs[0], i); ok {
+							scan.varTypes[ident.Name] = ref
+						}
+					}
+				}
+				return true
+			}
+			if len(node.Lhs) != len(node.Rhs) {
+				return true
+			}
+			for i, lhs := range node.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok {
+					if ref, ok := scan.valueType(node.Rhs[i]); ok {
+						scan.varTypes[ident.Name] = ref
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			for i, name := range node.Names {
+				if node.Type != nil {
+					scan.varTypes[name.Name] = typeRef{file: file, expr: node.Type}
+				} else if i < len(node.Values) {
+					if ref, ok := scan.valueType(node.Values[i]); ok {
+						scan.varTypes[name.Name] = ref
+					}
+				}
+			}
+		}
+		return true
+	})
+	return scan
+}
+
+// valueType returns the static type of a composite literal, its address, a new(T) call or the
+// first result of a function of the scanned packages.
+func (scan *handlerScan) valueType(expr ast.Expr) (typeRef, bool) {
+	switch expr := expr.(type) {
+	case *ast.CompositeLit:
+		if expr.Type != nil {
+			return typeRef{file: scan.file, expr: expr.Type}, true
+		}
+	case *ast.UnaryExpr:
+		return scan.valueType(expr.X)
+	case *ast.ParenExpr:
+		return scan.valueType(expr.X)
+	case *ast.CallExpr:
+		if ident, ok := expr.Fun.(*ast.Ident); ok && ident.Name == "new" && len(expr.Args) == 1 {
+			return typeRef{file: scan.file, expr: expr.Args[0]}, true
+		}
+		return scan.resultType(expr, 0)
+	}
+	return typeRef{}, false
+}
+
+// resultType returns the type of the i-th result of the function called by expr.
+func (scan *handlerScan) resultType(expr ast.Expr, i int) (typeRef, bool) {
+	callExpr, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return typeRef{}, false
+	}
+	funcDecl := scan.parser.findFuncDecl(scan.file, callExpr.Fun)
+	if funcDecl == nil || funcDecl.Type.Results == nil {
+		return typeRef{}, false
+	}
+	for _, field := range funcDecl.Type.Results.List {
+		count := len(field.Names)
+		if count == 0 {
+			count = 1
+		}
+		if i < count {
+			return typeRef{file: scan.parser.funcFiles[funcDecl], expr: field.Type}, true
+		}
+		i -= count
+	}
+	return typeRef{}, false
+}
+
+// typeOf returns the static type of an argument like &req, req or &model.Pets{}.
+func (scan *handlerScan) typeOf(expr ast.Expr) (typeRef, bool) {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		ref, ok := scan.varTypes[expr.Name]
+		return ref, ok
+	case *ast.UnaryExpr:
+		return scan.typeOf(expr.X)
+	case *ast.ParenExpr:
+		return scan.typeOf(expr.X)
+	}
+	return scan.valueType(expr)
+}
+
+// ctxMethod returns the name of the *gin.Context method called by callExpr.
+func (scan *handlerScan) ctxMethod(callExpr *ast.CallExpr) (string, bool) {
+	selectorExpr, ok := callExpr.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", false
+	}
+	ident, ok := selectorExpr.X.(*ast.Ident)
+	if !ok || !scan.ctxNames[ident.Name] {
+		return "", false
+	}
+	return selectorExpr.Sel.Name, true
+}
+
+// inferParameters returns the parameters read by the body of a gin handler through its *gin.Context,
+// including the helpers it passes the context to, one call deep.
+func (p *Parser) inferParameters(file *ast.File, funcDecl *ast.FuncDecl) []spec.Parameter {
+	ctxNames := ginContextParams(file, funcDecl)
+	if funcDecl.Body == nil || len(ctxNames) == 0 {
+		return nil
+	}
+	var params []spec.Parameter
+	p.scanParameters(p.newHandlerScan(file, funcDecl, ctxNames), funcDecl.Body, &params, 0)
+	return params
+}
+
+// scanParameters appends the parameters read in body to params.
+func (p *Parser) scanParameters(scan *handlerScan, body *ast.BlockStmt, params *[]spec.Parameter, depth int) {
+	ast.Inspect(body, func(node ast.Node) bool {
+		callExpr, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		method, ok := scan.ctxMethod(callExpr)
+		if !ok {
+			if depth == 0 {
+				if helper, helperScan := p.helperScan(scan, callExpr); helper != nil {
+					p.scanParameters(helperScan, helper.Body, params, 1)
+				}
+			}
+			return true
+		}
+		if in, ok := structBinding(method, callExpr.Args); ok {
+			if ref, ok := scan.typeOf(callExpr.Args[0]); ok {
+				for _, param := range p.structParameters(ref.file, ref.expr, in) {
+					*params = appendParameter(*params, param)
+				}
+			}
+			return true
+		}
+		if param, ok := p.accessorParameter(scan, method, callExpr.Args); ok {
+			*params = appendParameter(*params, param)
+		}
+		return true
+	})
+}
+
+// structBinding returns the location of the values bound into a struct by a call of the given
+// *gin.Context method, like ShouldBindQuery(&req) or ShouldBindWith(&req, binding.Query).
+func structBinding(method string, args []ast.Expr) (string, bool) {
+	if len(args) == 0 {
+		return "", false
+	}
+	if in, ok := ginStructBinders[method]; ok {
+		return in, true
+	}
+	if (method == "BindWith" || method == "MustBindWith" || method == "ShouldBindWith") && len(args) == 2 {
+		if engine, ok := args[1].(*ast.SelectorExpr); ok {
+			in, ok := ginBindingLocations[engine.Sel.Name]
+			return in, ok
+		}
+	}
+	return "", false
+}
+
+// helperScan returns a function the *gin.Context is passed to by callExpr and its scan, nil otherwise.
+func (p *Parser) helperScan(scan *handlerScan, callExpr *ast.CallExpr) (*ast.FuncDecl, *handlerScan) {
+	funcDecl := p.findFuncDecl(scan.file, callExpr.Fun)
+	if funcDecl == nil || funcDecl.Body == nil {
+		return nil, nil
+	}
+	ctxNames := make(map[string]bool)
+	i := 0
+	for _, field := range funcDecl.Type.Params.List {
+		for _, name := range field.Names {
+			if i < len(callExpr.Args) {
+				if ident, ok := callExpr.Args[i].(*ast.Ident); ok && scan.ctxNames[ident.Name] {
+					ctxNames[name.Name] = true
+				}
+			}
+			i++
+		}
+	}
+	if len(ctxNames) == 0 {
+		return nil, nil
+	}
+	return funcDecl, p.newHandlerScan(p.funcFiles[funcDecl], funcDecl, ctxNames)
+}
+
+// accessorParameter returns the parameter read by a call of the given *gin.Context method.
+func (p *Parser) accessorParameter(scan *handlerScan, method string, args []ast.Expr) (spec.Parameter, bool) {
+	if ginBodyBinders[method] || method == "BindWith" || method == "MustBindWith" || method == "ShouldBindWith" {
+		if len(args) == 0 {
+			return spec.Parameter{}, false
+		}
+		if len(args) == 2 && method != "ShouldBindBodyWith" {
+			if engine, ok := args[1].(*ast.SelectorExpr); !ok || !ginBindingBodies[engine.Sel.Name] {
+				return spec.Parameter{}, false
+			}
+		}
+		ref, ok := scan.typeOf(args[0])
+		if !ok {
+			return spec.Parameter{}, false
+		}
+		param := createParameter("body", "", "body", "object", true)
+		schema := p.parseTypeSchema(ref.file, ref.expr)
+		param.Schema = &schema
+		return param, true
+	}
+
+	paramType, ok := ginParamAccessors[method]
+	if !ok || len(args) == 0 {
+		return spec.Parameter{}, false
+	}
+	name, ok := p.stringValue(scan.file, args[0])
+	if !ok {
+		return spec.Parameter{}, false
+	}
+	if paramType == "cookie" {
+		return cookieParameter(name, "", false), true
+	}
+	schemaType := "string"
+	if method == "FormFile" {
+		schemaType = "file"
+	}
+	param := createParameter(paramType, "", name, schemaType, paramType == "path")
+	switch method {
+	case "DefaultQuery", "DefaultPostForm":
+		if len(args) == 2 {
+			if defaultValue, ok := p.stringValue(scan.file, args[1]); ok {
+				param.Default = defaultValue
+			}
+		}
+	case "QueryArray", "GetQueryArray", "PostFormArray", "GetPostFormArray":
+		param.Type = "array"
+		param.Items = &spec.Items{SimpleSchema: spec.SimpleSchema{Type: "string"}}
+		param.CollectionFormat = "multi"
+	}
+	return param, true
+}
+
+// appendParameter appends param unless a parameter of the same name and location, or another body, exists.
+// Cookie parameters are merged into the Cookie header parameter.
+func appendParameter(params []spec.Parameter, param spec.Parameter) []spec.Parameter {
+	for i, existing := range params {
+		if existing.In == param.In && (existing.Name == param.Name || param.In == "body") {
+			if cookies, ok := param.Extensions["x-cookies"].([]cookie); ok {
+				params[i] = mergeCookieParameter(existing, param, cookies)
+			}
+			return params
+		}
+	}
+	return append(params, param)
+}
+
+// ginResponder describes a *gin.Context method writing a response: the argument index of the status
+// code and of the value, or the fixed schema type when the value is not a Go value to document.
+type ginResponder struct {
+	code        int
+	value       int
+	schemaType  string
+	contentType string
+}
+
+// ginResponders maps the *gin.Context methods writing a response to their description.
+var ginResponders = map[string]ginResponder{
+	"JSON":                {code: 0, value: 1, contentType: "application/json"},
+	"IndentedJSON":        {code: 0, value: 1, contentType: "application/json"},
+	"SecureJSON":          {code: 0, value: 1, contentType: "application/json"},
+	"PureJSON":            {code: 0, value: 1, contentType: "application/json"},
+	"AsciiJSON":           {code: 0, value: 1, contentType: "application/json"},
+	"JSONP":               {code: 0, value: 1, contentType: "application/javascript"},
+	"AbortWithStatusJSON": {code: 0, value: 1, contentType: "application/json"},
+	"XML":                 {code: 0, value: 1, contentType: "application/xml"},
+	"YAML":                {code: 0, value: 1, contentType: "application/x-yaml"},
+	"String":              {code: 0, value: -1, schemaType: "string", contentType: "text/plain"},
+	"HTML":                {code: 0, value: -1, schemaType: "string", contentType: "text/html"},
+	"Data":                {code: 0, value: -1, schemaType: "file"}, // the content type is its second argument
+	"AbortWithStatus":     {code: 0, value: -1},
+	"Status":              {code: 0, value: -1},
+	"Redirect":            {code: 0, value: -1},
+}
+
+// httpStatusCodes maps the names of the net/http status constants to their code.
+var httpStatusCodes = map[string]int{
+	"StatusContinue":                      http.StatusContinue,
+	"StatusSwitchingProtocols":            http.StatusSwitchingProtocols,
+	"StatusProcessing":                    http.StatusProcessing,
+	"StatusEarlyHints":                    http.StatusEarlyHints,
+	"StatusOK":                            http.StatusOK,
+	"StatusCreated":                       http.StatusCreated,
+	"StatusAccepted":                      http.StatusAccepted,
+	"StatusNonAuthoritativeInfo":          http.StatusNonAuthoritativeInfo,
+	"StatusNoContent":                     http.StatusNoContent,
+	"StatusResetContent":                  http.StatusResetContent,
+	"StatusPartialContent":                http.StatusPartialContent,
+	"StatusMultiStatus":                   http.StatusMultiStatus,
+	"StatusAlreadyReported":               http.StatusAlreadyReported,
+	"StatusIMUsed":                        http.StatusIMUsed,
+	"StatusMultipleChoices":               http.StatusMultipleChoices,
+	"StatusMovedPermanently":              http.StatusMovedPermanently,
+	"StatusFound":                         http.StatusFound,
+	"StatusSeeOther":                      http.StatusSeeOther,
+	"StatusNotModified":                   http.StatusNotModified,
+	"StatusUseProxy":                      http.StatusUseProxy,
+	"StatusTemporaryRedirect":             http.StatusTemporaryRedirect,
+	"StatusPermanentRedirect":             http.StatusPermanentRedirect,
+	"StatusBadRequest":                    http.StatusBadRequest,
+	"StatusUnauthorized":                  http.StatusUnauthorized,
+	"StatusPaymentRequired":               http.StatusPaymentRequired,
+	"StatusForbidden":                     http.StatusForbidden,
+	"StatusNotFound":                      http.StatusNotFound,
+	"StatusMethodNotAllowed":              http.StatusMethodNotAllowed,
+	"StatusNotAcceptable":                 http.StatusNotAcceptable,
+	"StatusProxyAuthRequired":             http.StatusProxyAuthRequired,
+	"StatusRequestTimeout":                http.StatusRequestTimeout,
+	"StatusConflict":                      http.StatusConflict,
+	"StatusGone":                          http.StatusGone,
+	"StatusLengthRequired":                http.StatusLengthRequired,
+	"StatusPreconditionFailed":            http.StatusPreconditionFailed,
+	"StatusRequestEntityTooLarge":         http.StatusRequestEntityTooLarge,
+	"StatusRequestURITooLong":             http.StatusRequestURITooLong,
+	"StatusUnsupportedMediaType":          http.StatusUnsupportedMediaType,
+	"StatusRequestedRangeNotSatisfiable":  http.StatusRequestedRangeNotSatisfiable,
+	"StatusExpectationFailed":             http.StatusExpectationFailed,
+	"StatusTeapot":                        http.StatusTeapot,
+	"StatusMisdirectedRequest":            http.StatusMisdirectedRequest,
+	"StatusUnprocessableEntity":           http.StatusUnprocessableEntity,
+	"StatusLocked":                        http.StatusLocked,
+	"StatusFailedDependency":              http.StatusFailedDependency,
+	"StatusTooEarly":                      http.StatusTooEarly,
+	"StatusUpgradeRequired":               http.StatusUpgradeRequired,
+	"StatusPreconditionRequired":          http.StatusPreconditionRequired,
+	"StatusTooManyRequests":               http.StatusTooManyRequests,
+	"StatusRequestHeaderFieldsTooLarge":   http.StatusRequestHeaderFieldsTooLarge,
+	"StatusUnavailableForLegalReasons":    http.StatusUnavailableForLegalReasons,
+	"StatusInternalServerError":           http.StatusInternalServerError,
+	"StatusNotImplemented":                http.StatusNotImplemented,
+	"StatusBadGateway":                    http.StatusBadGateway,
+	"StatusServiceUnavailable":            http.StatusServiceUnavailable,
+	"StatusGatewayTimeout":                http.StatusGatewayTimeout,
+	"StatusHTTPVersionNotSupported":       http.StatusHTTPVersionNotSupported,
+	"StatusVariantAlsoNegotiates":         http.StatusVariantAlsoNegotiates,
+	"StatusInsufficientStorage":           http.StatusInsufficientStorage,
+	"StatusLoopDetected":                  http.StatusLoopDetected,
+	"StatusNotExtended":                   http.StatusNotExtended,
+	"StatusNetworkAuthenticationRequired": http.StatusNetworkAuthenticationRequired,
+}
+
+// inferResponses returns the responses written by the body of a gin handler through its *gin.Context,
+// including the helpers it passes the context to, and the content types they are written with.
+func (p *Parser) inferResponses(file *ast.File, funcDecl *ast.FuncDecl) (map[int]spec.Response, []string) {
+	ctxNames := ginContextParams(file, funcDecl)
+	if funcDecl.Body == nil || len(ctxNames) == 0 {
+		return nil, nil
+	}
+	responses := make(map[int]spec.Response)
+	var produces []string
+	p.scanResponses(p.newHandlerScan(file, funcDecl, ctxNames), funcDecl.Body, responses, &produces, 0)
+	return responses, produces
+}
+
+// scanResponses adds the responses written in body to responses and their content types to produces.
+func (p *Parser) scanResponses(scan *handlerScan, body *ast.BlockStmt, responses map[int]spec.Response, produces *[]string, depth int) {
+	ast.Inspect(body, func(node ast.Node) bool {
+		callExpr, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		method, ok := scan.ctxMethod(callExpr)
+		if !ok {
+			if depth == 0 {
+				if helper, helperScan := p.helperScan(scan, callExpr); helper != nil {
+					p.scanResponses(helperScan, helper.Body, responses, produces, 1)
+				}
+			}
+			return true
+		}
+		responder, ok := ginResponders[method]
+		if !ok || len(callExpr.Args) <= responder.code || len(callExpr.Args) <= responder.value {
+			return true
+		}
+		code, ok := scan.statusCode(callExpr.Args[responder.code])
+		if !ok {
+			return true
+		}
+		contentType := responder.contentType
+		if method == "Data" && len(callExpr.Args) > 1 {
+			contentType, _ = p.stringValue(scan.file, callExpr.Args[1])
+		}
+		if contentType != "" && !containsString(*produces, contentType) {
+			*produces = append(*produces, contentType)
+		}
+		if _, ok := responses[code]; ok {
+			return true
+		}
+		response := spec.Response{ResponseProps: spec.ResponseProps{Description: http.StatusText(code)}}
+		if responder.schemaType != "" {
+			response.Schema = &spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{responder.schemaType}}}
+		} else if responder.value >= 0 {
+			if schema, ok := scan.schemaOf(callExpr.Args[responder.value]); ok {
+				response.Schema = &schema
+			}
+		}
+		responses[code] = response
+		return true
+	})
+}
+
+// statusCode returns the value of an integer literal, a net/http status or another integer constant.
+func (scan *handlerScan) statusCode(expr ast.Expr) (int, bool) {
+	if selectorExpr, ok := expr.(*ast.SelectorExpr); ok {
+		if pkg, ok := selectorExpr.X.(*ast.Ident); ok && importedPath(scan.file, pkg.Name) == "net/http" {
+			code, ok := httpStatusCodes[selectorExpr.Sel.Name]
+			return code, ok
+		}
+		if pkg, ok := selectorExpr.X.(*ast.Ident); ok {
+			if value, ok := scan.parser.constants[importedPackage(scan.file, pkg.Name)+"."+selectorExpr.Sel.Name]; ok {
+				return intConstant(value)
+			}
+		}
+		return 0, false
+	}
+	return intConstant(scan.parser.evalConst(scan.file.Name.Name, expr, 0))
+}
+
+// intConstant returns the value of an integer constant, or of a float constant like 200.0 with an integer value.
+func intConstant(value constant.Value) (int, bool) {
+	if value = constant.ToInt(value); value.Kind() != constant.Int {
+		return 0, false
+	}
+	code, ok := constant.Int64Val(value)
+	return int(code), ok
+}
+
+// schemaOf returns the schema of a response value: a literal, a gin.H literal or a value of known type.
+func (scan *handlerScan) schemaOf(expr ast.Expr) (spec.Schema, bool) {
+	switch expr := expr.(type) {
+	case *ast.BasicLit:
+		switch expr.Kind {
+		case token.STRING:
+			return spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"string"}}}, true
+		case token.INT:
+			return spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"integer"}}}, true
+		case token.FLOAT:
+			return spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"number"}}}, true
+		}
+	case *ast.Ident:
+		if expr.Name == "true" || expr.Name == "false" {
+			return spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"boolean"}}}, true
+		}
+	case *ast.CompositeLit:
+		if isGinH(scan.file, expr.Type) {
+			properties := make(map[string]spec.Schema)
+			for _, elt := range expr.Elts {
+				keyValue, ok := elt.(*ast.KeyValueExpr)
+				if !ok {
+					continue
+				}
+				key, ok := scan.parser.stringValue(scan.file, keyValue.Key)
+				if !ok {
+					continue
+				}
+				property, _ := scan.schemaOf(keyValue.Value)
+				properties[key] = property
+			}
+			return spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"object"}, Properties: properties}}, true
+		}
+	}
+	ref, ok := scan.typeOf(expr)
+	if !ok {
+		return spec.Schema{}, false
+	}
+	return scan.parser.parseTypeSchema(ref.file, ref.expr), true
+}
+
+// isGinH reports whether typeExpr is gin.H.
+func isGinH(file *ast.File, typeExpr ast.Expr) bool {
+	selectorExpr, ok := typeExpr.(*ast.SelectorExpr)
+	return ok && selectorExpr.Sel.Name == "H" && isGinPackage(file, selectorExpr.X)
+}
+
+// importedPath returns the import path of the package imported as localName in file.
+func importedPath(file *ast.File, localName string) string {
+	for _, importSpec := range file.Imports {
+		importPath, _ := strconv.Unquote(importSpec.Path.Value)
+		if importSpec.Name != nil {
+			if importSpec.Name.Name == localName {
+				return importPath
+			}
+		} else if path.Base(importPath) == localName {
+			return importPath
+		}
+	}
+	return ""
+}
+
+// containsString reports whether values contains value.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+// fixtureOperation parses a main.go registering handler at GET /x and returns its operation.
+func fixtureOperation(t *testing.T, src string) *spec.Operation {
+	t.Helper()
+	parser := parseFixture(t, map[string]string{"main.go": src})
+	if len(parser.Diagnostics) > 0 {
+		t.Fatalf("unexpected diagnostics: %v", parser.Diagnostics)
+	}
+	item, ok := parser.swagger.Paths.Paths["/x"]
+	if !ok || item.Get == nil {
+		t.Fatalf("GET /x is not documented")
+	}
+	return item.Get
+}
+
+// describeParameters returns the "in name type required" of params, sorted.
+func describeParameters(params []spec.Parameter) []string {
+	var described []string
+	for _, param := range params {
+		described = append(described, fmt.Sprintf("%s %s %s %t", param.In, param.Name, param.Type, param.Required))
+	}
+	sort.Strings(described)
+	return described
+}
+
+func TestInferStructParameters(t *testing.T) {
+	const header = `package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+func main() {
+	r := gin.New()
+	r.GET("/x", handle)
+}
+
+type Paging struct {
+	Page  int ` + "`form:\"page\"`" + `
+	Limit int ` + "`form:\"limit\" binding:\"max=100\"`" + `
+}
+
+type ListReq struct {
+	Paging
+	Name   string   ` + "`form:\"name\" binding:\"required\"`" + `
+	Tags   []string ` + "`form:\"tag\"`" + `
+	Secret string   ` + "`form:\"-\"`" + `
+}
+
+type Filter struct {
+	Name   string ` + "`form:\"name\"`" + `
+	Parent *Filter
+}
+
+type Headers struct {
+	RequestID string ` + "`header:\"X-Request-Id\" binding:\"required\"`" + `
+}
+`
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "ShouldBindQuery",
+			body: `var req ListReq
+	c.ShouldBindQuery(&req)`,
+			want: []string{
+				"query limit integer false",
+				"query name string true",
+				"query page integer false",
+				"query tag array false",
+			},
+		},
+		{
+			name: "ShouldBindWith binding.Form",
+			body: `req := &ListReq{}
+	c.ShouldBindWith(req, binding.Form)`,
+			want: []string{
+				"query limit integer false",
+				"query name string true",
+				"query page integer false",
+				"query tag array false",
+			},
+		},
+		{
+			name: "ShouldBindWith binding.FormPost",
+			body: `var req Paging
+	c.ShouldBindWith(&req, binding.FormPost)`,
+			want: []string{
+				"formData limit integer false",
+				"formData page integer false",
+			},
+		},
+		{
+			name: "struct containing itself",
+			body: `var f Filter
+	c.ShouldBindQuery(&f)`,
+			want: []string{
+				"query name string false",
+			},
+		},
+		{
+			name: "ShouldBindHeader",
+			body: `var h Headers
+	if err := c.ShouldBindHeader(&h); err != nil {
+		return
+	}`,
+			want: []string{
+				"header X-Request-Id string true",
+			},
+		},
+		{
+			name: "ShouldBindUri",
+			body: `var uri struct {
+		ID string ` + "`uri:\"id\"`" + `
+	}
+	c.ShouldBindUri(&uri)`,
+			want: nil, // GET /x has no {id}, inferred path params not in the route are dropped
+		},
+		{
+			name: "ShouldBindJSON is a body",
+			body: `var req ListReq
+	c.ShouldBindJSON(&req)`,
+			want: []string{
+				"body body  true",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			operation := fixtureOperation(t, header+"\nfunc handle(c *gin.Context) {\n\t"+test.body+"\n}\n")
+			if got := describeParameters(operation.Parameters); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got parameters %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// describeResponses returns the "code type-or-ref" of the responses of operation, sorted.
+func describeResponses(operation *spec.Operation) []string {
+	var described []string
+	if operation.Responses == nil {
+		return nil
+	}
+	for code, response := range operation.Responses.StatusCodeResponses {
+		schema := "-"
+		if response.Schema != nil {
+			schema = strings.Join(response.Schema.Type, ",")
+			if ref := response.Schema.Ref.String(); ref != "" {
+				schema = ref
+			}
+		}
+		described = append(described, fmt.Sprintf("%d %s", code, schema))
+	}
+	sort.Strings(described)
+	return described
+}
+
+func TestInferResponses(t *testing.T) {
+	const header = `package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func main() {
+	r := gin.New()
+	r.GET("/x", handle)
+}
+
+type Pet struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+const teapot = 418
+`
+	tests := []struct {
+		name     string
+		body     string
+		want     []string
+		produces []string
+	}{
+		{
+			name:     "http.StatusXxx constants",
+			body:     `c.JSON(http.StatusOK, Pet{})`,
+			want:     []string{"200 #/definitions/main.Pet"},
+			produces: []string{"application/json"},
+		},
+		{
+			name: "several status codes",
+			body: `if c.Query("q") == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "q"})
+		return
+	}
+	c.String(http.StatusCreated, "ok")
+	c.Status(http.StatusNoContent)`,
+			want:     []string{"201 string", "204 -", "400 object"},
+			produces: []string{"application/json", "text/plain"},
+		},
+		{
+			name:     "literal and constant codes",
+			body:     `c.XML(teapot, []Pet{}); c.Data(202, "image/png", nil)`,
+			want:     []string{"202 file", "418 array"},
+			produces: []string{"application/xml", "image/png"},
+		},
+		{
+			name:     "float literal code",
+			body:     `c.JSON(200.0, Pet{})`,
+			want:     []string{"200 #/definitions/main.Pet"},
+			produces: []string{"application/json"},
+		},
+		{
+			name:     "status of a helper",
+			body:     `respond(c)`,
+			want:     []string{"503 string"},
+			produces: []string{"text/plain"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src := header + "\nfunc handle(c *gin.Context) {\n\t" + test.body + "\n}\n" +
+				"\nfunc respond(c *gin.Context) {\n\tc.String(http.StatusServiceUnavailable, \"down\")\n}\n"
+			operation := fixtureOperation(t, src)
+			if got := describeResponses(operation); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got responses %q, want %q", got, test.want)
+			}
+			if !reflect.DeepEqual(operation.Produces, test.produces) {
+				t.Errorf("got produces %q, want %q", operation.Produces, test.produces)
+			}
+		})
+	}
+}
+
+func TestExplicitResponsesWin(t *testing.T) {
+	operation := fixtureOperation(t, `package main
+
+import "github.com/gin-gonic/gin"
+
+func main() {
+	r := gin.New()
+	r.GET("/x", handle)
+}
+
+// @Success 200 {string} string "ok"
+// @Success 201 {integer} int64 "count"
+// @Failure 404 {file} file "missing"
+// @Router /x [get]
+func handle(c *gin.Context) {
+	c.String(200, "ok")
+}
+`)
+	want := []string{"200 string", "201 integer", "404 file"}
+	if got := describeResponses(operation); !reflect.DeepEqual(got, want) {
+		t.Errorf("got responses %q, want %q", got, want)
+	}
+	if format := operation.Responses.StatusCodeResponses[201].Schema.Format; format != "int64" {
+		t.Errorf("got format %q of 201, want int64", format)
+	}
+}
@@ -4,35 +4,43 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"github.com/go-openapi/jsonreference"
 	"github.com/go-openapi/spec"
 	"go/ast"
+	"go/constant"
 	"go/parser"
 	"go/token"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
 
-var (
-	mainFile *string = flag.String("main", "main.go", "use -main <mainfile>")
+const (
+	// SnakeCase names untagged fields like field_name.
+	SnakeCase = "snakecase"
+	// CamelCase names untagged fields like fieldName.
+	CamelCase = "camelcase"
+	// PascalCase keeps the Go field name, as encoding/json does.
+	PascalCase = "pascalcase"
 )
 
 func main() {
-	flag.Parse()
-	dir , _ := filepath.Abs("./")
-	parse := NewParser()
-	parse.ParseApi(dir, *mainFile)
-	swag := parse.swagger
-	b, _ := json.MarshalIndent(swag, "", "")
-	docs, _ := os.Create(path.Join(dir, "swagger.json"))
-	defer docs.Close()
-	docs.Write(b)
+	if err := Run(os.Args[1:]); err != nil {
+		switch err {
+		case flag.ErrHelp:
+			return
+		case errFailed:
+		default:
+			log.Println(err)
+		}
+		os.Exit(1)
+	}
 }
 
 // Parser implements a parser for Go source files.
@@ -40,17 +48,69 @@ type Parser struct {
 	// swagger represents the root document object for the API specification
 	swagger *spec.Swagger
 
+	// fileSet holds the positions of every parsed file
+	fileSet *token.FileSet
+
 	//files is a map that stores map[real_go_file_path][astFile]
 	files map[string]*ast.File
 
 	// TypeDefinitions is a map that stores [package name][type name][*ast.TypeSpec]
 	TypeDefinitions map[string]map[string]*ast.TypeSpec
 
+	// typeFiles is a map that stores [*ast.TypeSpec][astFile declaring it]
+	typeFiles map[*ast.TypeSpec]*ast.File
+
 	//registerTypes is a map that stores [refTypeName][*ast.TypeSpec]
 	registerTypes map[string]*ast.TypeSpec
 
 	//定义的类
 	Definitions map[string]*ast.TypeSpec
+
+	// funcDecls is a map that stores [package name.func name][*ast.FuncDecl]
+	funcDecls map[string]*ast.FuncDecl
+
+	// methodDecls is a map that stores [method name][methods of that name]
+	methodDecls map[string][]*ast.FuncDecl
+
+	// funcFiles is a map that stores [*ast.FuncDecl][astFile declaring it]
+	funcFiles map[*ast.FuncDecl]*ast.File
+
+	// routes is a map that stores [handler][routes it is registered at with gin]
+	routes map[*ast.FuncDecl][]route
+
+	// constants is a map that stores [package name.const name][value]
+	constants map[string]constant.Value
+
+	// expanding is a set of the type specs whose schema is being built, to stop at recursive embedding
+	expanding map[*ast.TypeSpec]bool
+
+	// enums is a map that stores [package name.type name][typed constants of the type]
+	enums map[string][]enumValue
+
+	// TypeMappings is a map that stores [qualified type name][schema used for fields of that type]
+	TypeMappings map[string]spec.Schema
+
+	// StrictPathParams makes a route segment without a path @Param an error instead of a generated string param
+	StrictPathParams bool
+
+	// PropNamingStrategy names properties of fields without a json tag: snakecase, camelcase or pascalcase
+	PropNamingStrategy string
+
+	// Envelope is the definition wrapping the 2xx responses of every operation in its EnvelopeField property
+	Envelope      string
+	EnvelopeField string
+
+	// operationSources is a map that stores [METHOD path][handler documented there]
+	operationSources map[string]*ast.FuncDecl
+
+	// Excludes are the paths, relative to a search dir, and globs of the files not to parse
+	Excludes []string
+
+	// Verbose prints the files, routes and operations found
+	Verbose bool
+
+	// Diagnostics lists the problems found while parsing, parsing goes on to report them all
+	Diagnostics []Diagnostic
 }
 
 type Operation struct {
@@ -59,6 +119,15 @@ type Operation struct {
 	spec.Operation
 
 	parser *Parser // TODO: we don't need it
+
+	// file is the source file declaring the handler, used to resolve imported types
+	file *ast.File
+
+	// routes are the routes of the @Router comments, one operation is documented per route
+	routes []route
+
+	// noEnvelope opts out of the Envelope of the parser
+	noEnvelope bool
 }
 
 // NewOperation creates a new Operation with default properties.
@@ -88,82 +157,220 @@ func NewParser() *Parser {
 				Definitions: make(map[string]spec.Schema),
 			},
 		},
+		fileSet:         token.NewFileSet(),
 		files:           make(map[string]*ast.File),
 		TypeDefinitions: make(map[string]map[string]*ast.TypeSpec),
+		typeFiles:       make(map[*ast.TypeSpec]*ast.File),
 		registerTypes:   make(map[string]*ast.TypeSpec),
 		Definitions:     make(map[string]*ast.TypeSpec),
+		TypeMappings:    make(map[string]spec.Schema),
+		funcDecls:       make(map[string]*ast.FuncDecl),
+		methodDecls:     make(map[string][]*ast.FuncDecl),
+		funcFiles:       make(map[*ast.FuncDecl]*ast.File),
+		routes:          make(map[*ast.FuncDecl][]route),
+		constants:       make(map[string]constant.Value),
+		enums:           make(map[string][]enumValue),
+		expanding:       make(map[*ast.TypeSpec]bool),
+
+		operationSources: make(map[string]*ast.FuncDecl),
+
+		PropNamingStrategy: SnakeCase,
+	}
+	for typeName, schema := range wellKnownTypes {
+		parser.MapType(typeName, schema)
 	}
 	return parser
 }
 
 func (p *Parser) ParseApi(dir string, main string) {
-	p.getAllGoFileInfo(dir)
-	p.getApiInfo(filepath.Join(dir, main))
+	p.ParseApis([]string{dir}, main)
+}
 
-	for _, astFile := range p.files {
-		p.ParseType(astFile)
+// ParseApis parses the Go files of every search dir, main being relative to the first one.
+func (p *Parser) ParseApis(dirs []string, main string) {
+	for _, dir := range dirs {
+		p.getAllGoFileInfo(dir)
+	}
+	p.getApiInfo(filepath.Join(dirs[0], main))
+
+	paths := make([]string, 0, len(p.files))
+	for path := range p.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths) // documents the handlers in a stable order, so the same one wins a conflict
+	for _, path := range paths {
+		p.ParseType(p.files[path])
 	}
-	for _, astFile := range p.files {
-		p.ParseRouterApiInfo(astFile)
+	for _, path := range paths { // after the types of every file, to tell conversions from calls
+		p.parseConsts(p.files[path])
+	}
+	p.ParseRoutes()
+	for _, path := range paths {
+		p.ParseRouterApiInfo(p.files[path])
 	}
 	p.ParseDefinitions()
 }
 
 func (p *Parser) getAllGoFileInfo(dir string) {
 	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			p.reportAt(token.Position{Filename: path}, SeverityError, CodeRead, "%v", err)
+			return nil
+		}
+		if rel, relErr := filepath.Rel(dir, path); relErr == nil && rel != "." && p.excluded(rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 		if ext := filepath.Ext(path); ext == ".go" && !strings.Contains(path, "vendor") {
-			astFile, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ParseComments)
-			if err != nil {
-				panic(err)
+			if _, ok := p.files[path]; ok { // search dirs can overlap
+				return nil
 			}
+			astFile, err := parser.ParseFile(p.fileSet, path, nil, parser.ParseComments)
+			if err != nil { // the other files are still documented
+				p.reportParseError(path, err)
+				return nil
+			}
+			p.logf("parsed %s", path)
 			p.files[path] = astFile
 		}
 		return nil
 	})
 }
 
+// excluded reports whether the path relative to a search dir matches one of the Excludes: a path
+// it is in, or a glob matching the path or its file name.
+func (p *Parser) excluded(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	for _, exclude := range p.Excludes {
+		exclude = strings.TrimSuffix(filepath.ToSlash(filepath.Clean(exclude)), "/")
+		if rel == exclude || strings.HasPrefix(rel, exclude+"/") {
+			return true
+		}
+		if matched, _ := path.Match(exclude, rel); matched {
+			return true
+		}
+		if matched, _ := path.Match(exclude, path.Base(rel)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// logf prints the progress of the parser when it is Verbose.
+func (p *Parser) logf(format string, args ...interface{}) {
+	if p.Verbose {
+		log.Printf(format, args...)
+	}
+}
+
 func (p *Parser) getApiInfo(main string) {
-	fileSet := token.NewFileSet()
-	fileTree, err := parser.ParseFile(fileSet, main, nil, parser.ParseComments)
+	p.swagger.Swagger = "2.0"
+	fileTree, err := parser.ParseFile(p.fileSet, main, nil, parser.ParseComments)
 	if err != nil {
-		log.Panicf("ParseGeneralApiInfo occur error:%+v", err)
+		p.reportParseError(main, err)
+		return
+	}
+	var scheme *spec.SecurityScheme // the security definition being declared
+	schemePositions := make(map[string]token.Pos)
+	var securityPositions []token.Pos // the @security line of every global requirement
+	handlerDocs := make(map[*ast.CommentGroup]bool)
+	for _, decl := range fileTree.Decls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok && funcDecl.Name.Name != "main" {
+			handlerDocs[funcDecl.Doc] = true
+		}
 	}
-	p.swagger.Swagger = "2.0"
 	if fileTree.Comments != nil {
 		for _, comment := range fileTree.Comments {
-			for _, commentLine := range strings.Split(comment.Text(), "\n") {
-				attribute := strings.ToLower(strings.Split(commentLine, " ")[0])
-				switch attribute {
-				case "@version":
-					p.swagger.Info.Version = strings.TrimSpace(commentLine[len(attribute):])
-				case "@title":
-					p.swagger.Info.Title = strings.TrimSpace(commentLine[len(attribute):])
-				case "@description":
-					p.swagger.Info.Description = strings.TrimSpace(commentLine[len(attribute):])
-				case "@termsofservice":
-					p.swagger.Info.TermsOfService = strings.TrimSpace(commentLine[len(attribute):])
-				case "@contact.name":
-					p.swagger.Info.Contact.Name = strings.TrimSpace(commentLine[len(attribute):])
-				case "@contact.email":
-					p.swagger.Info.Contact.Email = strings.TrimSpace(commentLine[len(attribute):])
-				case "@contact.url":
-					p.swagger.Info.Contact.URL = strings.TrimSpace(commentLine[len(attribute):])
-				case "@license.name":
-					p.swagger.Info.License.Name = strings.TrimSpace(commentLine[len(attribute):])
-				case "@license.url":
-					p.swagger.Info.License.URL = strings.TrimSpace(commentLine[len(attribute):])
-				case "@host":
-					p.swagger.Host = strings.TrimSpace(commentLine[len(attribute):])
-				case "@basepath":
-					p.swagger.BasePath = strings.TrimSpace(commentLine[len(attribute):])
-				case "@schemes":
-					p.swagger.Schemes = GetSchemes(commentLine)
-				case "@tags":
-					p.swagger.Tags = append(p.swagger.Tags, GetTags(commentLine))
+			if handlerDocs[comment] { // documents an operation, not the api
+				continue
+			}
+			for _, c := range comment.List {
+				for _, commentLine := range strings.Split(commentText(c), "\n") {
+					attribute := strings.ToLower(strings.Split(commentLine, " ")[0])
+					if newScheme, ok := securitySchemes[attribute]; ok {
+						if p.swagger.SecurityDefinitions == nil {
+							p.swagger.SecurityDefinitions = make(spec.SecurityDefinitions)
+						}
+						scheme = newScheme()
+						p.swagger.SecurityDefinitions[strings.TrimSpace(commentLine[len(attribute):])] = scheme
+						schemePositions[strings.TrimSpace(commentLine[len(attribute):])] = c.Pos()
+						continue
+					}
+					switch attribute {
+					case "@version":
+						p.swagger.Info.Version = strings.TrimSpace(commentLine[len(attribute):])
+					case "@title":
+						p.swagger.Info.Title = strings.TrimSpace(commentLine[len(attribute):])
+					case "@description":
+						p.swagger.Info.Description = strings.TrimSpace(commentLine[len(attribute):])
+					case "@termsofservice":
+						p.swagger.Info.TermsOfService = strings.TrimSpace(commentLine[len(attribute):])
+					case "@contact.name":
+						p.swagger.Info.Contact.Name = strings.TrimSpace(commentLine[len(attribute):])
+					case "@contact.email":
+						p.swagger.Info.Contact.Email = strings.TrimSpace(commentLine[len(attribute):])
+					case "@contact.url":
+						p.swagger.Info.Contact.URL = strings.TrimSpace(commentLine[len(attribute):])
+					case "@license.name":
+						p.swagger.Info.License.Name = strings.TrimSpace(commentLine[len(attribute):])
+					case "@license.url":
+						p.swagger.Info.License.URL = strings.TrimSpace(commentLine[len(attribute):])
+					case "@host":
+						p.swagger.Host = strings.TrimSpace(commentLine[len(attribute):])
+					case "@basepath":
+						p.swagger.BasePath = strings.TrimSpace(commentLine[len(attribute):])
+					case "@schemes":
+						p.swagger.Schemes = GetSchemes(commentLine)
+					case "@tags":
+						p.swagger.Tags = append(p.swagger.Tags, GetTags(commentLine))
+					case "@in", "@name", "@authorizationurl", "@tokenurl":
+						if err := ParseSecurityAttribute(scheme, attribute, strings.TrimSpace(commentLine[len(attribute):])); err != nil {
+							p.report(c.Pos(), SeverityError, CodeSecurity, "%v", err)
+						}
+					case "@envelope":
+						fields := strings.Fields(commentLine[len(attribute):])
+						if len(fields) != 2 {
+							p.report(c.Pos(), SeverityError, CodeGeneralInfo, "can not parse envelope comment \"%s\", expected @envelope Type field", commentLine)
+							continue
+						}
+						if _, err := definitionRef(strings.TrimPrefix(fields[0], "@")); err != nil {
+							p.report(c.Pos(), SeverityError, CodeGeneralInfo, "%v", err)
+							continue
+						}
+						p.Envelope, p.EnvelopeField = fields[0], fields[1]
+					case "@security":
+						security, err := ParseSecurityComment(strings.TrimSpace(commentLine[len(attribute):]))
+						if err != nil {
+							p.report(c.Pos(), SeverityError, CodeSecurity, "%v", err)
+						}
+						for _, requirement := range security {
+							p.swagger.Security = append(p.swagger.Security, requirement)
+							securityPositions = append(securityPositions, c.Pos())
+						}
+					default:
+						if strings.HasPrefix(attribute, "@scope.") {
+							if err := ParseSecurityAttribute(scheme, commentLine[:len(attribute)], strings.TrimSpace(commentLine[len(attribute):])); err != nil {
+								p.report(c.Pos(), SeverityError, CodeSecurity, "%v", err)
+							}
+						}
+					}
 				}
 			}
 		}
 	}
+	p.validateSecurityDefinitions(schemePositions)
+	for i, requirement := range p.swagger.Security { // schemes can be declared after the @security line
+		if err := p.checkSecurity([]map[string][]string{requirement}); err != nil {
+			p.report(securityPositions[i], SeverityError, CodeSecurity, "%v", err)
+		}
+	}
+}
+
+// commentText returns the text of a single comment without its markers, like ast.CommentGroup.Text.
+func commentText(comment *ast.Comment) string {
+	return (&ast.CommentGroup{List: []*ast.Comment{comment}}).Text()
 }
 
 func (p *Parser) ParseType(file *ast.File) {
@@ -175,6 +382,7 @@ func (p *Parser) ParseType(file *ast.File) {
 			for _, astSpec := range generalDeclaration.Specs {
 				if typeSpec, ok := astSpec.(*ast.TypeSpec); ok {
 					p.TypeDefinitions[file.Name.String()][typeSpec.Name.String()] = typeSpec
+					p.typeFiles[typeSpec] = file
 				}
 			}
 		}
@@ -192,7 +400,12 @@ func (p *Parser) ParseType(file *ast.File) {
 						genDecl := astDeclaration.(*ast.GenDecl)
 						if genDecl.Tok == token.TYPE {
 							if realType, ok := astDec.Specs[0].(*ast.TypeSpec); ok {
-								p.Definitions[strings.TrimSpace(text[len("@def"):])] = realType
+								name := strings.TrimSpace(text[len("@def"):])
+								if _, err := definitionRef(name); err != nil {
+									p.report(comment.Pos(), SeverityError, CodeComment, "%v", err)
+									continue
+								}
+								p.Definitions[name] = realType
 							}
 						}
 					}
@@ -202,101 +415,197 @@ func (p *Parser) ParseType(file *ast.File) {
 	}
 }
 
+// ParseRouterApiInfo parses the handlers of file into operations. A handler is documented at the
+// routes it is registered at with gin, unless its @Router comment overrides them.
 func (p *Parser) ParseRouterApiInfo(file *ast.File) {
 	for _, astDescription := range file.Decls {
 		switch astDeclaration := astDescription.(type) {
 		case *ast.FuncDecl:
-			if astDeclaration.Doc != nil && astDeclaration.Doc.List != nil {
-				operation := NewOperation() //for per 'function' comment, create a new 'Operation' object
-				operation.parser = p
+			if astDeclaration.Recv == nil && astDeclaration.Name.Name == "main" && file.Name.Name == "main" {
+				continue // documents the api, see getApiInfo
+			}
+			routes := p.routes[astDeclaration]
+			if (astDeclaration.Doc == nil || astDeclaration.Doc.List == nil) && len(routes) == 0 {
+				continue
+			}
+			operation := NewOperation() //for per 'function' comment, create a new 'Operation' object
+			operation.parser = p
+			operation.file = file
+			if astDeclaration.Doc != nil {
 				for _, comment := range astDeclaration.Doc.List {
-					if err := operation.ParseComment(comment.Text); err != nil {
-						log.Panicf("ParseComment panic:%+v", err)
+					if err := operation.ParseComment(comment.Text); err != nil { // the other comments are still documented
+						p.report(comment.Pos(), SeverityError, CodeComment, "%v", err)
 					}
 				}
-				var pathItem spec.PathItem
-				var ok bool
-
-				if pathItem, ok = p.swagger.Paths.Paths[operation.Path]; !ok {
-					pathItem = spec.PathItem{}
+			}
+			if p.Envelope != "" && !operation.noEnvelope {
+				operation.wrapEnvelope(p.Envelope, p.EnvelopeField)
+			}
+			explicitParams := len(operation.Parameters)
+			for _, param := range p.inferParameters(file, astDeclaration) { // explicit @Param comments win
+				operation.Parameters = appendParameter(operation.Parameters, param)
+			}
+			responses, produces := p.inferResponses(file, astDeclaration)
+			for code, response := range responses { // explicit @Success and @Failure comments win
+				if operation.Responses == nil {
+					operation.Responses = &spec.Responses{
+						ResponsesProps: spec.ResponsesProps{
+							StatusCodeResponses: make(map[int]spec.Response),
+						},
+					}
 				}
-				switch strings.ToUpper(operation.HttpMethod) {
-				case http.MethodGet:
-					pathItem.Get = &operation.Operation
-				case http.MethodPost:
-					pathItem.Post = &operation.Operation
-				case http.MethodDelete:
-					pathItem.Delete = &operation.Operation
-				case http.MethodPut:
-					pathItem.Put = &operation.Operation
-				case http.MethodPatch:
-					pathItem.Patch = &operation.Operation
-				case http.MethodHead:
-					pathItem.Head = &operation.Operation
-				case http.MethodOptions:
-					pathItem.Options = &operation.Operation
+				existing, ok := operation.Responses.StatusCodeResponses[code]
+				if !ok {
+					operation.Responses.StatusCodeResponses[code] = response
+				} else if existing.Schema == nil { // a code documented by @Header or @Example only
+					existing.Schema = response.Schema
+					operation.Responses.StatusCodeResponses[code] = existing
 				}
-
-				p.swagger.Paths.Paths[operation.Path] = pathItem
+			}
+			if operation.Responses != nil {
+				for code, response := range operation.Responses.StatusCodeResponses {
+					if response.Description == "" { // the description of a response is required
+						response.Description = http.StatusText(code)
+						operation.Responses.StatusCodeResponses[code] = response
+					}
+				}
+				if operation.Responses.Default != nil && operation.Responses.Default.Description == "" {
+					operation.Responses.Default.Description = "default"
+				}
+			}
+			if len(operation.Produces) == 0 {
+				operation.Produces = produces
+			}
+			if len(operation.Consumes) == 0 {
+				operation.Consumes = formConsumes(operation.Parameters)
+			}
+			if len(operation.routes) > 0 {
+				routes = operation.routes
+			}
+			for _, r := range routes {
+				params, err := p.pathParameters(r.Path, operation.Parameters, explicitParams)
+				if err != nil {
+					p.report(astDeclaration.Pos(), SeverityError, CodePathParam, "%v", err)
+					continue
+				}
+				routeOperation := operation.Operation
+				routeOperation.Parameters = params
+				p.addOperation(r.Method, r.Path, routeOperation, astDeclaration)
 			}
 		}
 	}
 }
 
-func (p *Parser) ParseDefinitions() {
-	for refTypeName, typeSpec := range p.Definitions {
-		var properties map[string]spec.Schema
-		properties = make(map[string]spec.Schema)
-
-		switch typeSpec.Type.(type) {
-		case *ast.StructType:
-			structDecl := typeSpec.Type.(*ast.StructType)
-			fields := structDecl.Fields.List
-			for _, field := range fields {
-				if len(field.Names) > 0 {
-					name := field.Names[0].Name
-					propName := getPropertyName(field)
-					name = snakeString(name)
-					r := spec.Schema{
-						SchemaProps: spec.SchemaProps{Type: []string{propName}},
+// pathParameters checks the path parameters of an operation against the {param} segments of path.
+// The first explicit parameters come from @Param comments, declaring one that is not part of the path
+// is an error, while inferred ones are dropped. Segments without a parameter get a required string
+// one, or are an error with StrictPathParams.
+func (p *Parser) pathParameters(path string, params []spec.Parameter, explicit int) ([]spec.Parameter, error) {
+	segments := pathParamRegexp.FindAllStringSubmatch(path, -1)
+	inPath := make(map[string]bool)
+	for _, segment := range segments {
+		inPath[segment[1]] = true
+	}
 
-					}
-					if propName == "array" || propName == "object" {
-						re := regexp.MustCompile(`swag\:\"(\w+)\"`)
-						s := re.FindStringSubmatch(field.Tag.Value)
-						if len(s) == 2 {
-							r.Items = &spec.SchemaOrArray{
-								Schema: &spec.Schema{
-									SchemaProps: spec.SchemaProps{
-										Ref: spec.Ref{Ref: jsonreference.MustCreateRef("#/definitions/" + s[1])},
-									},
-								},
-							}
-						}
-					}
-					properties[name] = r
+	var result []spec.Parameter
+	declared := make(map[string]bool)
+	for i, param := range params {
+		if param.In == "path" {
+			if !inPath[param.Name] {
+				if i < explicit {
+					return nil, fmt.Errorf("path param \"%s\" is not part of the route %s", param.Name, path)
 				}
+				continue
 			}
+			param.Required = true
+			declared[param.Name] = true
+		}
+		result = append(result, param)
+	}
+	for _, segment := range segments {
+		name := segment[1]
+		if declared[name] {
+			continue
 		}
+		if p.StrictPathParams {
+			return nil, fmt.Errorf("route %s has no @Param for its path param \"%s\"", path, name)
+		}
+		result = append(result, createParameter("path", "", name, "string", true))
+		declared[name] = true
+	}
+	return result, nil
+}
 
-		p.swagger.Definitions[refTypeName] = spec.Schema{
-			SchemaProps: spec.SchemaProps{
-				Type:       []string{"object"},
-				Properties: properties,
-			},
+// addOperation documents operation of handler at the given path and http method. A path and method
+// that is already documented keeps its operation and the clash is reported at the second handler.
+func (p *Parser) addOperation(httpMethod, path string, operation spec.Operation, handler *ast.FuncDecl) {
+	var pathItem spec.PathItem
+	var ok bool
+
+	key := strings.ToUpper(httpMethod) + " " + path
+	if first, ok := p.operationSources[key]; ok {
+		if first == handler { // the same handler reached twice at a route is no conflict
+			return
 		}
+		p.report(handler.Pos(), SeverityWarning, CodeRouteConflict, "%s %s is already documented by the handler at %s, this handler is ignored",
+			strings.ToUpper(httpMethod), path, p.fileSet.Position(first.Pos()))
+		return
+	}
+	p.operationSources[key] = handler
+	p.logf("documented %s %s", strings.ToUpper(httpMethod), path)
 
+	if pathItem, ok = p.swagger.Paths.Paths[path]; !ok {
+		pathItem = spec.PathItem{}
+	}
+	switch strings.ToUpper(httpMethod) {
+	case http.MethodGet:
+		pathItem.Get = &operation
+	case http.MethodPost:
+		pathItem.Post = &operation
+	case http.MethodDelete:
+		pathItem.Delete = &operation
+	case http.MethodPut:
+		pathItem.Put = &operation
+	case http.MethodPatch:
+		pathItem.Patch = &operation
+	case http.MethodHead:
+		pathItem.Head = &operation
+	case http.MethodOptions:
+		pathItem.Options = &operation
+	}
+
+	p.swagger.Paths.Paths[path] = pathItem
+}
+
+// ParseDefinitions builds every @def model and every type registered while parsing, including the
+// types they reference in turn.
+func (p *Parser) ParseDefinitions() {
+	for refTypeName, typeSpec := range p.Definitions {
+		p.registerTypes[refTypeName] = typeSpec
+	}
+	for {
+		var pending []string
+		for refTypeName := range p.registerTypes {
+			if _, ok := p.swagger.Definitions[refTypeName]; !ok {
+				pending = append(pending, refTypeName)
+			}
+		}
+		if len(pending) == 0 {
+			return
+		}
+		for _, refTypeName := range pending {
+			p.swagger.Definitions[refTypeName] = p.parseNamedTypeSchema(p.registerTypes[refTypeName])
+		}
 	}
 }
 
 // GetSchemes parses swagger schemes for gived commentLine
 func GetSchemes(commentLine string) []string {
-	attribute := strings.ToLower(strings.Split(commentLine, " ")[0])
-	return strings.Split(strings.TrimSpace(commentLine[len(attribute):]), " ")
+	return strings.Fields(commentLine)[1:]
 }
 
 func GetTags(commentLine string) spec.Tag {
-	attr := strings.Split(commentLine, " ")
+	attr := strings.Fields(commentLine)
 	var tag = *&spec.Tag{}
 	if len(attr) > 1 {
 		tag.TagProps = spec.TagProps{
@@ -341,10 +650,7 @@ func (operation *Operation) ParseComment(comment string) error {
 		if err := operation.ParseResponseComment(strings.TrimSpace(commentLine[len(attribute):])); err != nil {
 
 			if errWhenEmpty := operation.ParseEmptyResponseComment(strings.TrimSpace(commentLine[len(attribute):])); errWhenEmpty != nil {
-				var errs []string
-				errs = append(errs, err.Error())
-				errs = append(errs, errWhenEmpty.Error())
-				return fmt.Errorf(strings.Join(errs, "\n"))
+				return err // a diagnostic is a single line, the typed form explains what is expected
 			}
 		}
 
@@ -352,6 +658,29 @@ func (operation *Operation) ParseComment(comment string) error {
 		if err := operation.ParseRouterComment(strings.TrimSpace(commentLine[len(attribute):])); err != nil {
 			return err
 		}
+	case "@security":
+		security, err := ParseSecurityComment(strings.TrimSpace(commentLine[len(attribute):]))
+		if err != nil {
+			return err
+		}
+		if err := operation.parser.checkSecurity(security); err != nil {
+			return err
+		}
+		operation.Security = append(operation.Security, security...)
+	case "@header":
+		if err := operation.ParseHeaderComment(strings.TrimSpace(commentLine[len(attribute):])); err != nil {
+			return err
+		}
+	case "@example":
+		if err := operation.ParseExampleComment(strings.TrimSpace(commentLine[len(attribute):])); err != nil {
+			return err
+		}
+	case "@noenvelope":
+		operation.noEnvelope = true
+	case "@nosecurity": // opts out of the global @security of the general info
+		if operation.Security == nil {
+			operation.Security = []map[string][]string{}
+		}
 	}
 
 	return nil
@@ -364,9 +693,27 @@ func (operation *Operation) ParseComment(comment string) error {
 // @param page query string false  "页码"
 // @param name body model.ArticleTag true  "标签名称"
 // @param name body
+// @Param limit query int false "page size" Minimum(1) Maximum(100) Default(20)
+// @Param ids query []int false "pet ids" CollectionFormat(csv)
+// @Param query @ListPetsReq
 func (operation *Operation) ParseParamComment(commentLine string) error {
 	paramString := commentLine
 
+	if matches := regexp.MustCompile(`^(query|formData|header|path)[\s]+([@\w\.]+)$`).FindStringSubmatch(strings.TrimSpace(paramString)); len(matches) == 3 {
+		// a struct expanded into one param per field
+		typeSpec := operation.parser.findTypeByName(operation.file, strings.TrimPrefix(matches[2], "@"))
+		if typeSpec == nil {
+			return fmt.Errorf("Can not parse param comment \"%s\": unknown type \"%s\"", paramString, matches[2])
+		}
+		if _, ok := typeSpec.Type.(*ast.StructType); !ok {
+			return fmt.Errorf("Can not parse param comment \"%s\": \"%s\" is not a struct", paramString, matches[2])
+		}
+		for _, param := range operation.parser.structParameters(operation.parser.typeFiles[typeSpec], typeSpec.Name, matches[1]) {
+			operation.Operation.Parameters = appendParameter(operation.Operation.Parameters, param)
+		}
+		return nil
+	}
+
 	re := regexp.MustCompile(`([-\w]+)[\s]+([\w]+)[\s]+([\S.]+)[\s]+([\w]+)[\s]+"([^"]+)"`)
 
 	if matches := re.FindStringSubmatch(paramString); len(matches) != 6 {
@@ -382,31 +729,38 @@ func (operation *Operation) ParseParamComment(commentLine string) error {
 
 		var param spec.Parameter
 
-		//five possible parameter types.
+		attributes := paramString[re.FindStringIndex(paramString)[1]:]
+
+		//six possible parameter types, cookies are documented as the Cookie header.
 		switch paramType {
-		case "query", "path":
-			param = createParameter(paramType, description, name, schemaType, required)
-		case "body", "formData":
-			var schema string
-			if paramType == "body" {
-				schema = "object"
-			} else {
-				schema = "file"
+		case "query", "path", "header", "formData":
+			simpleSchema, err := paramSimpleSchema(schemaType)
+			if err != nil || (simpleSchema.Type == "file" && paramType != "formData") {
+				return fmt.Errorf("Can not parse param comment \"%s\": unknown %s param type \"%s\"", paramString, paramType, schemaType)
 			}
-			param = createParameter(paramType, description, name, schema, required) // TODO: if Parameter types can be objects, but also primitives and arrays
-
-			// TODO: this snippets have to extract out
-			if strings.Index(strings.TrimSpace(schemaType), "@") == 0 {
-				schemaType = schemaType[1:]
-				if ref, ok := operation.parser.Definitions[schemaType]; ok {
-					operation.parser.registerTypes[schemaType] = ref
-				}
-				param.Schema.Ref = spec.Ref{
-					Ref: jsonreference.MustCreateRef("#definitions/" + schemaType),
+			param = createParameter(paramType, description, name, simpleSchema.Type, required)
+			param.SimpleSchema = simpleSchema
+		case "body":
+			param = createParameter(paramType, description, name, "object", required) // TODO: if Parameter types can be objects, but also primitives and arrays
+
+			if refTypeName, ok := operation.refTypeName(schemaType); ok || strings.Index(strings.TrimSpace(schemaType), "@") == 0 {
+				ref, err := definitionRef(refTypeName)
+				if err != nil {
+					return fmt.Errorf("Can not parse param comment \"%s\": %v", paramString, err)
 				}
+				param.Schema.Ref = ref
 			}
-		case "header": // TODO: support Header and Form
-			param = createFormDataParameter(paramType, description, name, schemaType, required)
+		case "cookie":
+			if paramAttributeRegexp.MatchString(attributes) {
+				return fmt.Errorf("Can not parse param comment \"%s\": cookie params take no attributes", paramString)
+			}
+			operation.Operation.Parameters = appendParameter(operation.Operation.Parameters, cookieParameter(name, description, required))
+			return nil
+		default:
+			return fmt.Errorf("Can not parse param comment \"%s\": unknown param location \"%s\"", paramString, paramType)
+		}
+		if err := applyParamAttributes(&param, attributes); err != nil {
+			return fmt.Errorf("Can not parse param comment \"%s\": %v", paramString, err)
 		}
 		operation.Operation.Parameters = append(operation.Operation.Parameters, param)
 	}
@@ -456,7 +810,7 @@ func (operation *Operation) ParseProduceComment(commentLine string) error {
 }
 
 func (operation *Operation) ParseRouterComment(commentLine string) error {
-	re := regexp.MustCompile(`([\w\.\/\-{}]+)[^\[]+\[([^\]]+)`)
+	re := regexp.MustCompile(`([\w\.\/\-{}:\*]+)[^\[]+\[([^\]]+)`)
 	var matches []string
 
 	if matches = re.FindStringSubmatch(commentLine); len(matches) != 3 {
@@ -465,134 +819,213 @@ func (operation *Operation) ParseRouterComment(commentLine string) error {
 	path := matches[1]
 	httpMethod := matches[2]
 
-	operation.Path = path
+	operation.Path = swaggerPath(path)
 	operation.HttpMethod = strings.ToUpper(httpMethod)
+	operation.routes = append(operation.routes, route{Method: operation.HttpMethod, Path: operation.Path})
 
 	return nil
 }
 
 // @Success 200 {string} string model.File "ok"
+// @Failure default {object} @Error "unexpected error"
+// @Success 200 {object} @Envelope{data=[]@Pets} "ok"
 func (operation *Operation) ParseResponseComment(commentLine string) error {
-	re := regexp.MustCompile(`([\d]+)[\s]+([\w\{\}]+)[\s]+([@\w\-\.\/]+)[^"]*(.*)?`)
+	re := regexp.MustCompile(`([\d]+|default)[\s]+([\w\{\}]+)[\s]+([@\w\-\.\/]+(?:\{[^"]*\})?)[^"]*(.*)?`)
 	var matches []string
 
 	if matches = re.FindStringSubmatch(commentLine); len(matches) != 5 {
 		return fmt.Errorf("Can not parse response comment \"%s\".", commentLine)
 	}
 
-	response := spec.Response{}
-
-	code, _ := strconv.Atoi(matches[1])
+	response := operation.getResponse(matches[1]) // keeps the @Header and @Example of the code
 
 	response.Description = strings.Trim(matches[4], "\"")
 
 	resType := strings.Trim(matches[2], "{}")
-	dataType := matches[3]
-	if operation.parser != nil { // checking refType has existing in 'TypeDefinitions'
-		if strings.Index(strings.TrimSpace(dataType), "@") == 0 {
-			dataType = dataType[1:]
-			if ref, ok := operation.parser.Definitions[dataType]; ok {
-				operation.parser.registerTypes[dataType] = ref
-			}
-		}
-	}
+	dataType, _ := operation.refTypeName(matches[3])
 
 	// so we have to know all type in app
 	//TODO: we might omitted schema.type if schemaType equals 'object'
 	response.Schema = &spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"object"}}}
-	log.Println(resType)
-	if resType == "object" {
-		response.Schema.Ref = spec.Ref{
-			Ref: jsonreference.MustCreateRef("#/definitions/" + dataType),
-		}
-		response.Schema.Type = []string{"object"}
+	ref, err := definitionRef(dataType)
+	if err != nil && !strings.Contains(matches[3], "{") { // composite types are checked below
+		return fmt.Errorf("Can not parse response comment \"%s\": %v", commentLine, err)
 	}
-
-	if resType == "array" {
+	switch resType {
+	case "object":
+		response.Schema.Ref = ref
+		response.Schema.Type = []string{"object"}
+	case "array":
 		response.Schema.Items = &spec.SchemaOrArray{
 			Schema: &spec.Schema{
 				SchemaProps: spec.SchemaProps{
-					Ref: spec.Ref{Ref: jsonreference.MustCreateRef("#/definitions/" + dataType)},
+					Ref: ref,
 				},
 			},
 		}
 		response.Schema.Type = []string{"array"}
-
+	default: // primitives like {string} string or {integer} int64
+		schema, err := operation.typeSchema(strings.TrimSpace(matches[3]))
+		if err != nil || schema.Ref.String() != "" { // {file} and other types are named by themselves
+			schema = spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{resType}}}
+		}
+		response.Schema = &schema
 	}
 
-	if operation.Responses == nil {
-		operation.Responses = &spec.Responses{
-			ResponsesProps: spec.ResponsesProps{
-				StatusCodeResponses: make(map[int]spec.Response),
-			},
+	if strings.Contains(matches[3], "{") { // @Envelope{data=@Pets} overrides properties of a definition
+		composite, err := operation.compositeSchema(strings.TrimSpace(matches[3]))
+		if err != nil {
+			return fmt.Errorf("Can not parse response comment \"%s\": %v", commentLine, err)
+		}
+		if resType == "array" {
+			response.Schema.Items.Schema = &composite
+		} else {
+			response.Schema = &composite
 		}
 	}
 
-	operation.Responses.StatusCodeResponses[code] = response
+	operation.setResponse(matches[1], response)
 
 	return nil
 }
 
 func (operation *Operation) ParseEmptyResponseComment(commentLine string) error {
-	re := regexp.MustCompile(`([\d]+)[\s]+"(.*)"`)
+	re := regexp.MustCompile(`([\d]+|default)[\s]+"(.*)"`)
 	var matches []string
 
 	if matches = re.FindStringSubmatch(commentLine); len(matches) != 3 {
 		return fmt.Errorf("can not parse empty response comment \"%s\"", commentLine)
 	}
 
-	response := spec.Response{}
-
-	code, _ := strconv.Atoi(matches[1])
+	response := operation.getResponse(matches[1])
 
 	response.Description = strings.Trim(matches[2], "")
 
-	if operation.Responses == nil {
-		operation.Responses = &spec.Responses{
-			ResponsesProps: spec.ResponsesProps{
-				StatusCodeResponses: make(map[int]spec.Response),
-			},
+	operation.setResponse(matches[1], response)
+
+	return nil
+}
+
+// ParseHeaderComment adds a header to the responses of the given codes.
+// @Header 200 {string} X-Request-Id "request id"
+// @Header 200,400 {integer} X-Rate-Limit "requests left"
+func (operation *Operation) ParseHeaderComment(commentLine string) error {
+	re := regexp.MustCompile(`^([\d,]+|default)[\s]+\{([^}]+)\}[\s]+([-\w]+)[\s]*(.*)$`)
+	var matches []string
+
+	if matches = re.FindStringSubmatch(commentLine); len(matches) != 5 {
+		return fmt.Errorf("Can not parse header comment \"%s\".", commentLine)
+	}
+	simpleSchema, err := paramSimpleSchema(matches[2])
+	if err != nil || simpleSchema.Type == "file" {
+		return fmt.Errorf("Can not parse header comment \"%s\": unknown header type \"%s\"", commentLine, matches[2])
+	}
+	header := spec.Header{
+		SimpleSchema: simpleSchema,
+		HeaderProps:  spec.HeaderProps{Description: strings.Trim(matches[4], "\"")},
+	}
+	for _, code := range strings.Split(matches[1], ",") {
+		response := operation.getResponse(code)
+		headers := make(map[string]spec.Header)
+		for name, existing := range response.Headers {
+			headers[name] = existing
 		}
+		headers[matches[3]] = header
+		response.Headers = headers
+		operation.setResponse(code, response)
+	}
+	return nil
+}
+
+// ParseExampleComment adds an example of the given mime type to the response of a code, written inline
+// as JSON or read from a file relative to the handler's source file.
+// @Example 200 application/json {"id": 1, "name": "kitty"}
+// @Example 200 application/json examples/pet.json
+func (operation *Operation) ParseExampleComment(commentLine string) error {
+	re := regexp.MustCompile(`^([\d]+|default)[\s]+([-\w\.\+]+/[-\w\.\+]+)[\s]+(.+)$`)
+	var matches []string
+
+	if matches = re.FindStringSubmatch(commentLine); len(matches) != 4 {
+		return fmt.Errorf("Can not parse example comment \"%s\".", commentLine)
 	}
+	mimeType, text := matches[2], strings.TrimSpace(matches[3])
 
-	operation.Responses.StatusCodeResponses[code] = response
+	var example interface{}
+	if err := json.Unmarshal([]byte(text), &example); err != nil {
+		exampleFile := text
+		if !filepath.IsAbs(exampleFile) && operation.parser != nil && operation.file != nil {
+			exampleFile = filepath.Join(filepath.Dir(operation.parser.fileSet.Position(operation.file.Pos()).Filename), exampleFile)
+		}
+		b, err := ioutil.ReadFile(exampleFile)
+		if err != nil {
+			return fmt.Errorf("Can not parse example comment \"%s\": %v", commentLine, err)
+		}
+		if !strings.Contains(mimeType, "json") {
+			example = string(b)
+		} else if err := json.Unmarshal(b, &example); err != nil {
+			return fmt.Errorf("Can not parse example comment \"%s\": %s is not valid JSON: %v", commentLine, text, err)
+		}
+	}
 
+	response := operation.getResponse(matches[1])
+	examples := make(map[string]interface{})
+	for existingType, existing := range response.Examples {
+		examples[existingType] = existing
+	}
+	examples[mimeType] = example
+	response.Examples = examples
+	operation.setResponse(matches[1], response)
 	return nil
 }
 
-// createParamter returns swagger spec.Parameter for gived  paramType, description, paramName, schemaType, required
-func createParameter(paramType, description, paramName, schemaType string, required bool) spec.Parameter {
-	// //five possible parameter types. 	query, path, body, header, form
-	paramProps := spec.ParamProps{
-		Name:        paramName,
-		Description: description,
-		Required:    required,
-		In:          paramType,
+// getResponse returns the response documented for a status code, or for "default".
+func (operation *Operation) getResponse(code string) spec.Response {
+	if operation.Responses == nil {
+		return spec.Response{}
 	}
-	if paramType == "body" {
-		paramProps.Schema = &spec.Schema{
-			SchemaProps: spec.SchemaProps{
-				Type: []string{schemaType},
-			},
-		}
-		parameter := spec.Parameter{
-			ParamProps: paramProps,
+	if code == "default" {
+		if operation.Responses.Default != nil {
+			return *operation.Responses.Default
 		}
+		return spec.Response{}
+	}
+	status, _ := strconv.Atoi(code)
+	return operation.Responses.StatusCodeResponses[status]
+}
 
-		return parameter
-	} else {
-		parameter := spec.Parameter{
-			ParamProps: paramProps,
-			SimpleSchema: spec.SimpleSchema{
-				Type: schemaType,
+// setResponse documents response for a status code, or as the "default" response of the other codes.
+func (operation *Operation) setResponse(code string, response spec.Response) {
+	if operation.Responses == nil {
+		operation.Responses = &spec.Responses{
+			ResponsesProps: spec.ResponsesProps{
+				StatusCodeResponses: make(map[int]spec.Response),
 			},
 		}
-		return parameter
+	}
+	if code == "default" {
+		operation.Responses.Default = &response
+		return
+	}
+	status, _ := strconv.Atoi(code)
+	operation.Responses.StatusCodeResponses[status] = response
+}
 
+// refTypeName returns the definition name of a type referenced in a comment and registers it for ParseDefinitions.
+// The leading @ is optional and `model.Pets` is resolved through the imports of the handler file.
+func (operation *Operation) refTypeName(dataType string) (string, bool) {
+	dataType = strings.TrimPrefix(strings.TrimSpace(dataType), "@")
+	if operation.parser == nil { // checking refType has existing in 'TypeDefinitions'
+		return dataType, false
+	}
+	typeSpec := operation.parser.findTypeByName(operation.file, dataType)
+	if typeSpec == nil {
+		return dataType, false
 	}
+	return operation.parser.registerType(typeSpec), true
 }
 
-func createFormDataParameter(paramType, description, paramName, schemaType string, required bool) spec.Parameter {
+// createParamter returns swagger spec.Parameter for gived  paramType, description, paramName, schemaType, required
+func createParameter(paramType, description, paramName, schemaType string, required bool) spec.Parameter {
 	// //five possible parameter types. 	query, path, body, header, form
 	paramProps := spec.ParamProps{
 		Name:        paramName,
@@ -625,7 +1058,7 @@ func createFormDataParameter(paramType, description, paramName, schemaType strin
 
 // parseTag
 func (operation *Operation) ParseTagComment(commentLine string) []string {
-	attr := strings.Split(commentLine, " ")
+	attr := strings.Fields(commentLine)
 	var r = []string{}
 	if len(attr) > 1 {
 		r = append(r, attr[1])
@@ -635,57 +1068,3 @@ func (operation *Operation) ParseTagComment(commentLine string) []string {
 	}
 	return r
 }
-
-// getPropertyName returns the string value for the given field if it exists, otherwise it panics.
-// allowedValues: array, boolean, integer, null, number, object, string
-func getPropertyName(field *ast.Field) string {
-	var shouldTransInt = []string{"int64", "uint64", "int32", "uint32", "int8", "uint8"}
-	var shouldTransFloat = []string{"float64", "float32", "float8"}
-	var name string
-	if _, ok := field.Type.(*ast.SelectorExpr); ok {
-		// Support for time.Time as a structure field
-		return "string"
-
-	} else if astTypeIdent, ok := field.Type.(*ast.Ident); ok {
-		name = astTypeIdent.Name
-		for _, s := range shouldTransInt {
-			if s == name {
-				name = "number"
-			}
-		}
-		for _, s := range shouldTransFloat {
-			if s == name {
-				name = "number"
-			}
-		}
-	} else if _, ok := field.Type.(*ast.StarExpr); ok {
-		panic("not supported astStarExpr yet.")
-	} else if _, ok := field.Type.(*ast.MapType); ok { // if map
-		return "object"
-	} else if _, ok := field.Type.(*ast.ArrayType); ok { // if array
-		return "array"
-	} else if _, ok := field.Type.(*ast.StructType); ok { // if struct
-		return "object"
-	} else {
-		log.Fatalf("Something goes wrong: %#v", field.Type)
-	}
-
-	return name
-}
-
-func snakeString(s string) string {
-	data := make([]byte, 0, len(s)*2)
-	j := false
-	num := len(s)
-	for i := 0; i < num; i++ {
-		d := s[i]
-		if i > 0 && d >= 'A' && d <= 'Z' && j {
-			data = append(data, '_')
-		}
-		if d != '_' {
-			j = true
-		}
-		data = append(data, d)
-	}
-	return strings.ToLower(string(data[:]))
-}
@@ -0,0 +1,441 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// OpenAPIVersion is the version of the documents written by -openapi 3.
+const OpenAPIVersion = "3.0.3"
+
+// OpenAPI is the root of an OpenAPI 3.0 document.
+type OpenAPI struct {
+	OpenAPI      string                                  `json:"openapi"`
+	Info         *spec.Info                              `json:"info"`
+	Servers      []OpenAPIServer                         `json:"servers,omitempty"`
+	Paths        map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components   OpenAPIComponents                       `json:"components,omitempty"`
+	Security     []map[string][]string                   `json:"security,omitempty"`
+	Tags         []spec.Tag                              `json:"tags,omitempty"`
+	ExternalDocs *spec.ExternalDocumentation             `json:"externalDocs,omitempty"`
+}
+
+// OpenAPIServer is a server the API is available at.
+type OpenAPIServer struct {
+	URL string `json:"url"`
+}
+
+// OpenAPIComponents holds the schemas and security schemes referenced by the operations.
+type OpenAPIComponents struct {
+	Schemas         map[string]interface{}           `json:"schemas,omitempty"`
+	SecuritySchemes map[string]OpenAPISecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// OpenAPIOperation is an operation of a path.
+type OpenAPIOperation struct {
+	Tags         []string                    `json:"tags,omitempty"`
+	Summary      string                      `json:"summary,omitempty"`
+	Description  string                      `json:"description,omitempty"`
+	ExternalDocs *spec.ExternalDocumentation `json:"externalDocs,omitempty"`
+	OperationID  string                      `json:"operationId,omitempty"`
+	Parameters   []OpenAPIParameter          `json:"parameters,omitempty"`
+	RequestBody  *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses    map[string]OpenAPIResponse  `json:"responses"`
+	Deprecated   bool                        `json:"deprecated,omitempty"`
+	// Security is a pointer as an empty list, opting out of the global security, must be written
+	Security *[]map[string][]string `json:"security,omitempty"`
+}
+
+// OpenAPIParameter is a query, path, header or cookie parameter.
+type OpenAPIParameter struct {
+	Name        string      `json:"name"`
+	In          string      `json:"in"`
+	Description string      `json:"description,omitempty"`
+	Required    bool        `json:"required,omitempty"`
+	Style       string      `json:"style,omitempty"`
+	Explode     *bool       `json:"explode,omitempty"`
+	Schema      interface{} `json:"schema,omitempty"`
+}
+
+// OpenAPIRequestBody is the body of a request, a JSON document or a form.
+type OpenAPIRequestBody struct {
+	Description string                      `json:"description,omitempty"`
+	Required    bool                        `json:"required,omitempty"`
+	Content     map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIMediaType is the schema and example of a content type.
+type OpenAPIMediaType struct {
+	Schema  interface{} `json:"schema,omitempty"`
+	Example interface{} `json:"example,omitempty"`
+}
+
+// OpenAPIResponse is the response of a status code.
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Headers     map[string]OpenAPIHeader    `json:"headers,omitempty"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIHeader is a header of a response.
+type OpenAPIHeader struct {
+	Description string      `json:"description,omitempty"`
+	Schema      interface{} `json:"schema,omitempty"`
+}
+
+// OpenAPISecurityScheme is a security scheme: http basic, apiKey or oauth2.
+type OpenAPISecurityScheme struct {
+	Type        string             `json:"type"`
+	Description string             `json:"description,omitempty"`
+	Scheme      string             `json:"scheme,omitempty"`
+	Name        string             `json:"name,omitempty"`
+	In          string             `json:"in,omitempty"`
+	Flows       *OpenAPIOAuthFlows `json:"flows,omitempty"`
+}
+
+// OpenAPIOAuthFlows holds the flow of an oauth2 scheme.
+type OpenAPIOAuthFlows struct {
+	Implicit          *OpenAPIOAuthFlow `json:"implicit,omitempty"`
+	Password          *OpenAPIOAuthFlow `json:"password,omitempty"`
+	ClientCredentials *OpenAPIOAuthFlow `json:"clientCredentials,omitempty"`
+	AuthorizationCode *OpenAPIOAuthFlow `json:"authorizationCode,omitempty"`
+}
+
+// OpenAPIOAuthFlow is an oauth2 flow and its scopes.
+type OpenAPIOAuthFlow struct {
+	AuthorizationURL string            `json:"authorizationUrl,omitempty"`
+	TokenURL         string            `json:"tokenUrl,omitempty"`
+	Scopes           map[string]string `json:"scopes"`
+}
+
+// ConvertOpenAPI converts a swagger 2.0 document to OpenAPI 3.0: the definitions become components/schemas,
+// body and formData parameters a requestBody, responses are typed by the produced content types, host,
+// basePath and schemes become servers and securityDefinitions securitySchemes.
+func ConvertOpenAPI(swagger *spec.Swagger) *OpenAPI {
+	doc := &OpenAPI{
+		OpenAPI:      OpenAPIVersion,
+		Info:         swagger.Info,
+		Servers:      openAPIServers(swagger),
+		Paths:        make(map[string]map[string]*OpenAPIOperation),
+		Security:     swagger.Security,
+		Tags:         swagger.Tags,
+		ExternalDocs: swagger.ExternalDocs,
+	}
+	if swagger.Info != nil { // empty contact and license objects are invalid, a license needs a name
+		info := *swagger.Info
+		if info.Contact != nil && *info.Contact == (spec.ContactInfo{}) {
+			info.Contact = nil
+		}
+		if info.License != nil && info.License.Name == "" {
+			info.License = nil
+		}
+		doc.Info = &info
+	}
+	if len(swagger.Definitions) > 0 {
+		doc.Components.Schemas = make(map[string]interface{})
+		for name, schema := range swagger.Definitions {
+			doc.Components.Schemas[name] = openAPISchema(schema)
+		}
+	}
+	if len(swagger.SecurityDefinitions) > 0 {
+		doc.Components.SecuritySchemes = make(map[string]OpenAPISecurityScheme)
+		for name, scheme := range swagger.SecurityDefinitions {
+			doc.Components.SecuritySchemes[name] = openAPISecurityScheme(scheme)
+		}
+	}
+	if swagger.Paths == nil {
+		return doc
+	}
+	for path, pathItem := range swagger.Paths.Paths {
+		operations := make(map[string]*OpenAPIOperation)
+		for method, operation := range map[string]*spec.Operation{
+			"get":     pathItem.Get,
+			"put":     pathItem.Put,
+			"post":    pathItem.Post,
+			"delete":  pathItem.Delete,
+			"options": pathItem.Options,
+			"head":    pathItem.Head,
+			"patch":   pathItem.Patch,
+		} {
+			if operation != nil {
+				operations[method] = openAPIOperation(swagger, operation)
+			}
+		}
+		doc.Paths[path] = operations
+	}
+	return doc
+}
+
+// openAPIServers returns a server per scheme of the host and base path, a relative server without a host.
+func openAPIServers(swagger *spec.Swagger) []OpenAPIServer {
+	if swagger.Host == "" {
+		if swagger.BasePath == "" {
+			return nil
+		}
+		return []OpenAPIServer{{URL: swagger.BasePath}}
+	}
+	schemes := swagger.Schemes
+	if len(schemes) == 0 {
+		schemes = []string{"http"}
+	}
+	var servers []OpenAPIServer
+	for _, scheme := range schemes {
+		servers = append(servers, OpenAPIServer{URL: scheme + "://" + swagger.Host + swagger.BasePath})
+	}
+	return servers
+}
+
+// openAPIOperation converts an operation, taking the content types the document declares when it has none.
+func openAPIOperation(swagger *spec.Swagger, operation *spec.Operation) *OpenAPIOperation {
+	result := &OpenAPIOperation{
+		Tags:         operation.Tags,
+		Summary:      operation.Summary,
+		Description:  operation.Description,
+		ExternalDocs: operation.ExternalDocs,
+		OperationID:  operation.ID,
+		Responses:    make(map[string]OpenAPIResponse),
+		Deprecated:   operation.Deprecated,
+	}
+	if operation.Security != nil {
+		security := operation.Security
+		result.Security = &security
+	}
+	consumes := contentTypes(operation.Consumes, swagger.Consumes)
+	produces := contentTypes(operation.Produces, swagger.Produces)
+
+	form := spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"object"}, Properties: make(map[string]spec.Schema)}}
+	for _, param := range operation.Parameters {
+		switch param.In {
+		case "body":
+			body := &OpenAPIRequestBody{
+				Description: param.Description,
+				Required:    param.Required,
+				Content:     make(map[string]OpenAPIMediaType),
+			}
+			for _, contentType := range consumes {
+				body.Content[contentType] = OpenAPIMediaType{Schema: openAPISchema(*param.Schema)}
+			}
+			result.RequestBody = body
+		case "formData":
+			property := parameterSchema(param.SimpleSchema, param.CommonValidations)
+			property.Description = param.Description
+			form.Properties[param.Name] = property
+			if param.Required {
+				form.Required = append(form.Required, param.Name)
+			}
+		default:
+			result.Parameters = append(result.Parameters, openAPIParameters(param)...)
+		}
+	}
+	if len(form.Properties) > 0 {
+		formTypes := formConsumes(operation.Parameters)
+		if len(operation.Consumes) > 0 {
+			formTypes = operation.Consumes
+		}
+		result.RequestBody = &OpenAPIRequestBody{Content: make(map[string]OpenAPIMediaType)}
+		for _, contentType := range formTypes {
+			result.RequestBody.Content[contentType] = OpenAPIMediaType{Schema: openAPISchema(form)}
+		}
+	}
+
+	if operation.Responses != nil {
+		for code, response := range operation.Responses.StatusCodeResponses {
+			result.Responses[strconv.Itoa(code)] = openAPIResponse(response, produces)
+		}
+		if operation.Responses.Default != nil {
+			result.Responses["default"] = openAPIResponse(*operation.Responses.Default, produces)
+		}
+	}
+	return result
+}
+
+// contentTypes returns the content types of an operation, or those of the document, application/json by default.
+func contentTypes(operationTypes, documentTypes []string) []string {
+	if len(operationTypes) > 0 {
+		return operationTypes
+	}
+	if len(documentTypes) > 0 {
+		return documentTypes
+	}
+	return []string{"application/json"}
+}
+
+// openAPIParameters converts a query, path or header parameter. The Cookie header documenting cookies
+// becomes one cookie parameter per cookie.
+func openAPIParameters(param spec.Parameter) []OpenAPIParameter {
+	if cookies, ok := param.Extensions["x-cookies"].([]cookie); ok {
+		var params []OpenAPIParameter
+		for _, c := range cookies {
+			params = append(params, OpenAPIParameter{
+				Name:        c.Name,
+				In:          "cookie",
+				Description: c.Description,
+				Required:    c.Required,
+				Schema:      map[string]interface{}{"type": "string"},
+			})
+		}
+		return params
+	}
+	result := OpenAPIParameter{
+		Name:        param.Name,
+		In:          param.In,
+		Description: param.Description,
+		Required:    param.Required,
+		Schema:      openAPISchema(parameterSchema(param.SimpleSchema, param.CommonValidations)),
+	}
+	if param.Type == "array" {
+		explode := false
+		switch param.CollectionFormat {
+		case "multi":
+			explode = true
+			result.Style = "form"
+		case "ssv":
+			result.Style = "spaceDelimited"
+		case "pipes":
+			result.Style = "pipeDelimited"
+		default: // csv, and tsv that has no equivalent
+			if param.In == "query" {
+				result.Style = "form"
+			} else {
+				result.Style = "simple"
+			}
+		}
+		result.Explode = &explode
+	}
+	return []OpenAPIParameter{result}
+}
+
+// parameterSchema returns the schema of the type and validations of a non body parameter or of its items.
+func parameterSchema(simpleSchema spec.SimpleSchema, validations spec.CommonValidations) spec.Schema {
+	schema := spec.Schema{
+		SchemaProps: spec.SchemaProps{
+			Type:             []string{simpleSchema.Type},
+			Format:           simpleSchema.Format,
+			Default:          simpleSchema.Default,
+			Maximum:          validations.Maximum,
+			ExclusiveMaximum: validations.ExclusiveMaximum,
+			Minimum:          validations.Minimum,
+			ExclusiveMinimum: validations.ExclusiveMinimum,
+			MaxLength:        validations.MaxLength,
+			MinLength:        validations.MinLength,
+			Pattern:          validations.Pattern,
+			MaxItems:         validations.MaxItems,
+			MinItems:         validations.MinItems,
+			UniqueItems:      validations.UniqueItems,
+			MultipleOf:       validations.MultipleOf,
+			Enum:             validations.Enum,
+		},
+	}
+	if simpleSchema.Items != nil {
+		items := parameterSchema(simpleSchema.Items.SimpleSchema, simpleSchema.Items.CommonValidations)
+		schema.Items = &spec.SchemaOrArray{Schema: &items}
+	}
+	return schema
+}
+
+// openAPIResponse converts a response, its schema and examples typed by the produced content types.
+func openAPIResponse(response spec.Response, produces []string) OpenAPIResponse {
+	result := OpenAPIResponse{Description: response.Description}
+	for name, header := range response.Headers {
+		if result.Headers == nil {
+			result.Headers = make(map[string]OpenAPIHeader)
+		}
+		result.Headers[name] = OpenAPIHeader{
+			Description: header.Description,
+			Schema:      openAPISchema(parameterSchema(header.SimpleSchema, header.CommonValidations)),
+		}
+	}
+	if response.Schema == nil && len(response.Examples) == 0 {
+		return result
+	}
+	result.Content = make(map[string]OpenAPIMediaType)
+	if response.Schema != nil {
+		for _, contentType := range produces {
+			result.Content[contentType] = OpenAPIMediaType{Schema: openAPISchema(*response.Schema)}
+		}
+	}
+	for contentType, example := range response.Examples {
+		mediaType := result.Content[contentType]
+		mediaType.Example = example
+		result.Content[contentType] = mediaType
+	}
+	return result
+}
+
+// openAPISecurityScheme converts a security definition.
+func openAPISecurityScheme(scheme *spec.SecurityScheme) OpenAPISecurityScheme {
+	result := OpenAPISecurityScheme{Type: scheme.Type, Description: scheme.Description}
+	switch scheme.Type {
+	case "basic":
+		result.Type = "http"
+		result.Scheme = "basic"
+	case "apiKey":
+		result.Name = scheme.Name
+		result.In = scheme.In
+	case "oauth2":
+		scopes := scheme.Scopes
+		if scopes == nil {
+			scopes = map[string]string{}
+		}
+		flow := &OpenAPIOAuthFlow{AuthorizationURL: scheme.AuthorizationURL, TokenURL: scheme.TokenURL, Scopes: scopes}
+		result.Flows = &OpenAPIOAuthFlows{}
+		switch scheme.Flow {
+		case "implicit":
+			flow.TokenURL = ""
+			result.Flows.Implicit = flow
+		case "password":
+			result.Flows.Password = flow
+		case "application":
+			result.Flows.ClientCredentials = flow
+		case "accessCode":
+			result.Flows.AuthorizationCode = flow
+		}
+	}
+	return result
+}
+
+// openAPISchema converts a swagger 2.0 schema: references point to components/schemas, x-nullable becomes
+// nullable and file becomes a binary string.
+func openAPISchema(schema spec.Schema) interface{} {
+	b, err := json.Marshal(schema)
+	if err != nil {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(b, &value); err != nil {
+		return nil
+	}
+	return convertSchemaValue(value)
+}
+
+// convertSchemaValue converts the JSON value of a swagger 2.0 schema in place.
+func convertSchemaValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = convertSchemaValue(item)
+		}
+		if ref, ok := v["$ref"].(string); ok && strings.HasPrefix(ref, "#/definitions/") {
+			v["$ref"] = "#/components/schemas/" + ref[len("#/definitions/"):]
+		}
+		if nullable, ok := v["x-nullable"].(bool); ok {
+			delete(v, "x-nullable")
+			v["nullable"] = nullable
+		}
+		if v["type"] == "file" {
+			v["type"] = "string"
+			v["format"] = "binary"
+		}
+		if discriminator, ok := v["discriminator"].(string); ok {
+			v["discriminator"] = map[string]interface{}{"propertyName": discriminator}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = convertSchemaValue(item)
+		}
+	}
+	return value
+}
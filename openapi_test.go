@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+func TestConvertOpenAPI(t *testing.T) {
+	upload := spec.NewOperation("upload")
+	upload.Parameters = []spec.Parameter{
+		createParameter("formData", "the picture", "picture", "file", true),
+		createParameter("formData", "", "name", "string", false),
+	}
+	create := spec.NewOperation("create")
+	create.Parameters = []spec.Parameter{*spec.BodyParam("pet", spec.RefSchema("#/definitions/Pet")).AsRequired()}
+	list := spec.NewOperation("list")
+	list.Security = []map[string][]string{} // @NoSecurity
+	tags := createParameter("query", "", "tags", "array", false)
+	tags.Items = &spec.Items{SimpleSchema: spec.SimpleSchema{Type: "string"}}
+	tags.CollectionFormat = "csv"
+	ids := createParameter("query", "", "ids", "array", false)
+	ids.Items = &spec.Items{SimpleSchema: spec.SimpleSchema{Type: "integer"}}
+	ids.CollectionFormat = "multi"
+	list.Parameters = []spec.Parameter{
+		tags,
+		ids,
+		cookiesParameter([]cookie{{Name: "session", Description: "the session", Required: true}, {Name: "lang"}}),
+	}
+
+	owner := spec.RefSchema("#/definitions/Owner")
+	owner.AddExtension("x-nullable", true)
+	swagger := &spec.Swagger{
+		SwaggerProps: spec.SwaggerProps{
+			Security: []map[string][]string{{"ApiKeyAuth": {}}},
+			Paths: &spec.Paths{Paths: map[string]spec.PathItem{
+				"/pets":   {PathItemProps: spec.PathItemProps{Get: list, Post: create}},
+				"/upload": {PathItemProps: spec.PathItemProps{Post: upload}},
+			}},
+			Definitions: spec.Definitions{
+				"Pet": *new(spec.Schema).Typed("object", "").SetProperty("owner", *owner),
+			},
+		},
+	}
+	b, err := json.Marshal(ConvertOpenAPI(swagger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		pointer string
+		want    string
+	}{
+		{
+			name:    "formData params become a multipart requestBody, file a binary string",
+			pointer: "paths/~1upload/post/requestBody",
+			want:    `{"content":{"multipart/form-data":{"schema":{"properties":{"name":{"type":"string"},"picture":{"description":"the picture","format":"binary","type":"string"}},"required":["picture"],"type":"object"}}}}`,
+		},
+		{
+			name:    "body param becomes a requestBody",
+			pointer: "paths/~1pets/post/requestBody",
+			want:    `{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/Pet"}}},"required":true}`,
+		},
+		{
+			name:    "collectionFormat csv becomes style form without explode",
+			pointer: "paths/~1pets/get/parameters/0",
+			want:    `{"explode":false,"in":"query","name":"tags","schema":{"items":{"type":"string"},"type":"array"},"style":"form"}`,
+		},
+		{
+			name:    "collectionFormat multi becomes style form with explode",
+			pointer: "paths/~1pets/get/parameters/1",
+			want:    `{"explode":true,"in":"query","name":"ids","schema":{"items":{"type":"integer"},"type":"array"},"style":"form"}`,
+		},
+		{
+			name:    "Cookie header becomes cookie params",
+			pointer: "paths/~1pets/get/parameters/2",
+			want:    `{"description":"the session","in":"cookie","name":"session","required":true,"schema":{"type":"string"}}`,
+		},
+		{
+			name:    "Cookie header becomes cookie params, optional ones included",
+			pointer: "paths/~1pets/get/parameters/3",
+			want:    `{"in":"cookie","name":"lang","schema":{"type":"string"}}`,
+		},
+		{
+			name:    "@NoSecurity keeps an empty security",
+			pointer: "paths/~1pets/get/security",
+			want:    `[]`,
+		},
+		{
+			name:    "operations without security inherit the global one",
+			pointer: "paths/~1upload/post/security",
+			want:    `null`,
+		},
+		{
+			name:    "x-nullable becomes nullable, references point to components",
+			pointer: "components/schemas/Pet",
+			want:    `{"properties":{"owner":{"$ref":"#/components/schemas/Owner","nullable":true}},"type":"object"}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value := doc
+			for _, segment := range strings.Split(test.pointer, "/") {
+				segment = strings.Replace(segment, "~1", "/", -1)
+				switch v := value.(type) {
+				case map[string]interface{}:
+					value = v[segment]
+				case []interface{}:
+					value = nil
+					if i, err := strconv.Atoi(segment); err == nil && i < len(v) {
+						value = v[i]
+					}
+				default:
+					value = nil
+				}
+			}
+			got, err := json.Marshal(value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.want {
+				t.Errorf("got %s at %s, want %s", got, test.pointer, test.want)
+			}
+		})
+	}
+}
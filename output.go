@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/go-openapi/spec"
+	"gopkg.in/yaml.v2"
+)
+
+// The output formats of the -format flag.
+const (
+	// FormatJSON writes swagger.json.
+	FormatJSON = "json"
+	// FormatYAML writes swagger.yaml.
+	FormatYAML = "yaml"
+	// FormatBoth writes swagger.json and swagger.yaml.
+	FormatBoth = "both"
+)
+
+// jsonToYAML converts the JSON document doc to YAML with the same content and key order.
+func jsonToYAML(doc []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.UseNumber()
+	value, err := decodeOrdered(decoder)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(value)
+}
+
+// decodeOrdered decodes the next JSON value of decoder, objects as yaml.MapSlice keeping the order of their keys.
+func decodeOrdered(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch value := token.(type) {
+	case json.Delim:
+		switch value {
+		case '{':
+			object := yaml.MapSlice{}
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				item, err := decodeOrdered(decoder)
+				if err != nil {
+					return nil, err
+				}
+				object = append(object, yaml.MapItem{Key: key, Value: item})
+			}
+			_, err = decoder.Token() // }
+			return object, err
+		case '[':
+			array := []interface{}{}
+			for decoder.More() {
+				item, err := decodeOrdered(decoder)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, item)
+			}
+			_, err = decoder.Token() // ]
+			return array, err
+		}
+		return nil, fmt.Errorf("unexpected %v", value)
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i, nil
+		}
+		return value.Float64()
+	}
+	return token, nil
+}
+
+// docsTemplate is the Go package written by -output-go, registering the spec with swaggo/swag so that
+// gin-swagger serves it.
+var docsTemplate = template.Must(template.New("docs").Parse(`// Code generated by github.com/rookiejin/swagger. DO NOT EDIT.
+
+// Package {{.Package}} registers the swagger spec of the API with swaggo/swag.
+package {{.Package}}
+
+import (
+	"encoding/json"
+
+	"github.com/swaggo/swag"
+)
+
+// Info holds the parts of the spec that can be changed at runtime.
+type Info struct {
+	Host     string
+	BasePath string
+	Schemes  []string
+}
+
+// SwaggerInfo is used when the spec is read, set it before serving the docs to change its host, base path or schemes.
+var SwaggerInfo = Info{
+	Host:     {{printf "%q" .Host}},
+	BasePath: {{printf "%q" .BasePath}},
+	Schemes:  {{printf "%#v" .Schemes}},
+}
+
+var doc = ` + "`{{.Doc}}`" + `
+
+type s struct{}
+
+// ReadDoc returns the spec with the host, base path and schemes of SwaggerInfo.
+func (s *s) ReadDoc() string {
+	var spec map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(doc), &spec); err != nil {
+		return doc
+	}
+	set := func(key string, value interface{}, empty bool) {
+		if empty {
+			delete(spec, key)
+			return
+		}
+		b, _ := json.Marshal(value)
+		spec[key] = b
+	}
+	set("host", SwaggerInfo.Host, SwaggerInfo.Host == "")
+	set("basePath", SwaggerInfo.BasePath, SwaggerInfo.BasePath == "")
+	set("schemes", SwaggerInfo.Schemes, len(SwaggerInfo.Schemes) == 0)
+	b, err := json.MarshalIndent(spec, "", "    ")
+	if err != nil {
+		return doc
+	}
+	return string(b)
+}
+
+func init() {
+	swag.Register(swag.Name, &s{})
+}
+`))
+
+// writeDocsPackage writes doc, the JSON of swagger, as the Go package in dir registering it with swaggo/swag.
+// The package is named after dir when it is a valid identifier, docs otherwise.
+func writeDocsPackage(dir string, swagger *spec.Swagger, doc []byte) error {
+	packageName := filepath.Base(dir)
+	if !token.IsIdentifier(packageName) {
+		packageName = "docs"
+	}
+	schemes := swagger.Schemes
+	if schemes == nil {
+		schemes = []string{}
+	}
+	var source bytes.Buffer
+	err := docsTemplate.Execute(&source, map[string]interface{}{
+		"Package":  packageName,
+		"Host":     swagger.Host,
+		"BasePath": swagger.BasePath,
+		"Schemes":  schemes,
+		"Doc":      strings.Replace(string(doc), "`", "` + \"`\" + `", -1), // a raw string cannot hold backquotes
+	})
+	if err != nil {
+		return err
+	}
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "docs.go"), formatted, 0644)
+}
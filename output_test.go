@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+// yamlToJSON converts a value unmarshaled from YAML to one that encoding/json can marshal.
+func yamlToJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{})
+		for key, item := range v {
+			object[fmt.Sprint(key)] = yamlToJSON(item)
+		}
+		return object
+	case []interface{}:
+		for i, item := range v {
+			v[i] = yamlToJSON(item)
+		}
+	}
+	return value
+}
+
+func TestJSONToYAML(t *testing.T) {
+	doc := `{"swagger":"2.0","paths":{"/pets":{"get":{"responses":{"200":{"schema":{"$ref":"#/definitions/Pet"}}}}}},` +
+		`"definitions":{"Pet":{"type":"object","required":["name"],"properties":{"name":{"type":"string","example":"yes"},` +
+		`"age":{"type":"integer","maximum":120,"default":1.5},"tags":{"type":"array","items":{"type":"string"},"example":["1.0",""]}}}},` +
+		`"info":{"title":"t","version":"1"}}`
+	out, err := jsonToYAML([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the content is the same once read back
+	var value interface{}
+	if err := yaml.Unmarshal(out, &value); err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(yamlToJSON(value))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var original interface{}
+	if err := json.Unmarshal([]byte(doc), &original); err != nil {
+		t.Fatal(err)
+	}
+	want, err := json.Marshal(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("got %s back from YAML, want %s", got, want)
+	}
+
+	// the keys keep the order of the JSON document
+	var ordered yaml.MapSlice
+	if err := yaml.Unmarshal(out, &ordered); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, item := range ordered {
+		keys = append(keys, fmt.Sprint(item.Key))
+	}
+	if got, want := strings.Join(keys, ","), "swagger,paths,definitions,info"; got != want {
+		t.Errorf("got keys %s, want %s", got, want)
+	}
+	if name, age, tags := strings.Index(string(out), "name:"), strings.Index(string(out), "age:"), strings.Index(string(out), "tags:"); name > age || age > tags {
+		t.Errorf("properties are not in the order of the JSON document:\n%s", out)
+	}
+
+	// a $ref starts with a YAML comment unless quoted
+	if !strings.Contains(string(out), `$ref: '#/definitions/Pet'`) {
+		t.Errorf("$ref is not quoted in:\n%s", out)
+	}
+}
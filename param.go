@@ -0,0 +1,379 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// paramAttributeRegexp matches the attributes following the description of a @Param comment, like Minimum(1).
+var paramAttributeRegexp = regexp.MustCompile(`(\w+)\(([^)]*)\)`)
+
+// paramTypes are the swagger types a non body parameter can be declared with.
+var paramTypes = map[string]bool{
+	"string":  true,
+	"number":  true,
+	"integer": true,
+	"boolean": true,
+	"array":   true,
+	"file":    true,
+}
+
+// collectionFormats are the swagger formats of array parameters.
+var collectionFormats = map[string]bool{
+	"csv":   true,
+	"ssv":   true,
+	"tsv":   true,
+	"pipes": true,
+	"multi": true,
+}
+
+// paramSimpleSchema returns the schema of a non body @Param data type: a swagger type, a Go builtin
+// or []T for an array of either.
+func paramSimpleSchema(dataType string) (spec.SimpleSchema, error) {
+	if strings.HasPrefix(dataType, "[]") {
+		items, err := paramSimpleSchema(dataType[len("[]"):])
+		if err != nil {
+			return spec.SimpleSchema{}, err
+		}
+		return spec.SimpleSchema{Type: "array", Items: &spec.Items{SimpleSchema: items}}, nil
+	}
+	if paramTypes[dataType] {
+		return spec.SimpleSchema{Type: dataType}, nil
+	}
+	if schema, ok := basicSchema(dataType); ok {
+		return spec.SimpleSchema{Type: schema.Type[0], Format: schema.Format}, nil
+	}
+	return spec.SimpleSchema{}, fmt.Errorf("unknown param type \"%s\"", dataType)
+}
+
+// applyParamAttributes sets the attributes of a @Param comment on param:
+// Enums(a,b) Default(20) Minimum(1) Maximum(100) MinLength(1) MaxLength(64) Format(date-time) CollectionFormat(multi).
+// Enums and Default of an array apply to its items.
+func applyParamAttributes(param *spec.Parameter, attributes string) error {
+	for _, matches := range paramAttributeRegexp.FindAllStringSubmatch(attributes, -1) {
+		attribute, value := strings.ToLower(matches[1]), strings.TrimSpace(matches[2])
+		if param.In == "body" {
+			return fmt.Errorf("%s(%s) is not supported on body param \"%s\"", matches[1], value, param.Name)
+		}
+		switch attribute {
+		case "enums":
+			var enum []interface{}
+			for _, item := range strings.Split(value, ",") {
+				v, err := paramValue(param.SimpleSchema, strings.TrimSpace(item))
+				if err != nil {
+					return err
+				}
+				enum = append(enum, v)
+			}
+			if param.Items != nil {
+				param.Items.Enum = enum
+			} else {
+				param.Enum = enum
+			}
+		case "default":
+			if param.Items != nil {
+				var values []interface{}
+				for _, item := range strings.Split(value, ",") {
+					v, err := paramValue(param.SimpleSchema, strings.TrimSpace(item))
+					if err != nil {
+						return err
+					}
+					values = append(values, v)
+				}
+				param.Default = values
+				continue
+			}
+			v, err := paramValue(param.SimpleSchema, value)
+			if err != nil {
+				return err
+			}
+			param.Default = v
+		case "minimum", "maximum":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("%s(%s) of param \"%s\" is not a number", matches[1], value, param.Name)
+			}
+			if attribute == "minimum" {
+				param.Minimum = &f
+			} else {
+				param.Maximum = &f
+			}
+		case "minlength", "maxlength":
+			i, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("%s(%s) of param \"%s\" is not an integer", matches[1], value, param.Name)
+			}
+			if attribute == "minlength" {
+				param.MinLength = &i
+			} else {
+				param.MaxLength = &i
+			}
+		case "format":
+			param.Format = value
+		case "collectionformat":
+			if param.Type != "array" {
+				return fmt.Errorf("CollectionFormat(%s) of param \"%s\" needs an array type", value, param.Name)
+			}
+			if !collectionFormats[value] {
+				return fmt.Errorf("unknown collection format \"%s\" of param \"%s\"", value, param.Name)
+			}
+			param.CollectionFormat = value
+		default:
+			return fmt.Errorf("unknown attribute %s(%s) of param \"%s\"", matches[1], value, param.Name)
+		}
+	}
+	return nil
+}
+
+// paramValue converts value to the type of a parameter, or of its items when it is an array.
+func paramValue(schema spec.SimpleSchema, value string) (interface{}, error) {
+	if schema.Items != nil {
+		schema = schema.Items.SimpleSchema
+	}
+	v, ok := typedValue(&spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{schema.Type}}}, value)
+	if !ok {
+		return nil, fmt.Errorf("\"%s\" is not a valid %s", value, schema.Type)
+	}
+	return v, nil
+}
+
+// cookie is a cookie sent in the Cookie header, listed in the x-cookies extension of its parameter.
+type cookie struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// cookieParameter documents a cookie as the Cookie header, swagger 2.0 having no cookie parameters.
+func cookieParameter(cookieName, description string, required bool) spec.Parameter {
+	return cookiesParameter([]cookie{{Name: cookieName, Description: description, Required: required}})
+}
+
+// cookiesParameter returns the Cookie header parameter sending cookies, which is required when one of them is.
+func cookiesParameter(cookies []cookie) spec.Parameter {
+	var descriptions []string
+	required := false
+	for _, c := range cookies {
+		if c.Description != "" {
+			descriptions = append(descriptions, c.Name+": "+c.Description)
+		} else {
+			descriptions = append(descriptions, c.Name)
+		}
+		required = required || c.Required
+	}
+	param := createParameter("header", strings.Join(descriptions, "; "), "Cookie", "string", required)
+	param.AddExtension("x-cookies", cookies)
+	return param
+}
+
+// mergeCookieParameter adds the cookies of param to the Cookie header parameter existing. A Cookie
+// header declared as such is kept as it is.
+func mergeCookieParameter(existing, param spec.Parameter, cookies []cookie) spec.Parameter {
+	existingCookies, ok := existing.Extensions["x-cookies"].([]cookie)
+	if !ok {
+		return existing
+	}
+	merged := append([]cookie{}, existingCookies...)
+	for _, c := range cookies {
+		known := false
+		for _, existingCookie := range existingCookies {
+			known = known || existingCookie.Name == c.Name
+		}
+		if !known {
+			merged = append(merged, c)
+		}
+	}
+	return cookiesParameter(merged)
+}
+
+// formConsumes returns the content type of an operation with formData parameters: multipart/form-data
+// when one of them is a file, application/x-www-form-urlencoded otherwise.
+func formConsumes(params []spec.Parameter) []string {
+	var consumes []string
+	for _, param := range params {
+		if param.In != "formData" {
+			continue
+		}
+		if param.Type == "file" {
+			return []string{"multipart/form-data"}
+		}
+		consumes = []string{"application/x-www-form-urlencoded"}
+	}
+	return consumes
+}
+
+// structParamTags are the struct tags naming the fields of a struct expanded into parameters, by location.
+var structParamTags = map[string][]string{
+	"query":    {"form", "json"},
+	"formData": {"form", "json"},
+	"header":   {"header", "json"},
+	"path":     {"uri", "json"},
+}
+
+// structParameters expands the struct type typeExpr, written in file, into parameters in the given location.
+// Fields are named by their tags, or their Go name as gin does, and untagged struct fields are flattened.
+// Binding tags make a parameter required and constrain it, field comments describe it.
+func (p *Parser) structParameters(file *ast.File, typeExpr ast.Expr, in string) []spec.Parameter {
+	return p.expandStructParameters(file, typeExpr, in, make(map[*ast.TypeSpec]bool))
+}
+
+// expandStructParameters implements structParameters, expanding holds the struct types being expanded
+// to skip a struct that contains itself.
+func (p *Parser) expandStructParameters(file *ast.File, typeExpr ast.Expr, in string, expanding map[*ast.TypeSpec]bool) []spec.Parameter {
+	if star, ok := typeExpr.(*ast.StarExpr); ok {
+		typeExpr = star.X
+	}
+	structType, ok := typeExpr.(*ast.StructType)
+	if !ok {
+		typeSpec, typeFile := p.findTypeSpec(file, typeExpr)
+		if typeSpec == nil {
+			return nil
+		}
+		if structType, ok = typeSpec.Type.(*ast.StructType); !ok || expanding[typeSpec] {
+			return nil
+		}
+		expanding[typeSpec] = true
+		defer delete(expanding, typeSpec)
+		file = typeFile
+	}
+
+	var params []spec.Parameter
+	for _, field := range structType.Fields.List {
+		name, tagged := structParamName(field, in)
+		if name == "-" {
+			continue
+		}
+		if p.isStructField(file, field.Type) {
+			if !tagged { // gin binds the fields of untagged structs as if they were declared here
+				for _, param := range p.expandStructParameters(file, field.Type, in, expanding) {
+					params = appendParameter(params, param)
+				}
+			}
+			continue
+		}
+		var names []string
+		switch {
+		case tagged && len(field.Names) <= 1:
+			names = []string{name}
+		case len(field.Names) > 0:
+			for _, fieldName := range field.Names {
+				if fieldName.IsExported() {
+					names = append(names, fieldName.Name)
+				}
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+
+		schema := spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"file"}}}
+		if qualifiedTypeName(file, derefType(field.Type)) != "multipart.FileHeader" {
+			schema = p.parseTypeSchema(file, field.Type)
+		}
+		required := in == "path"
+		if rules, ok := parseBindingTag(field); ok {
+			required = applyBindingRules(&schema, rules) || required
+		}
+		applyFieldDoc(&schema, field)
+		for _, name := range names {
+			if param, ok := schemaParameter(in, name, schema, required); ok {
+				params = appendParameter(params, param)
+			}
+		}
+	}
+	return params
+}
+
+// structParamName returns the name of field from its tags for the given location, and whether it is tagged.
+func structParamName(field *ast.Field, in string) (string, bool) {
+	for _, key := range structParamTags[in] {
+		if value, ok := lookupTag(field, key); ok {
+			if name := strings.Split(value, ",")[0]; name != "" {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// isStructField reports whether typeExpr is a struct, or a pointer to one, that is not mapped to a schema.
+func (p *Parser) isStructField(file *ast.File, typeExpr ast.Expr) bool {
+	typeExpr = derefType(typeExpr)
+	if _, ok := p.TypeMappings[qualifiedTypeName(file, typeExpr)]; ok {
+		return false
+	}
+	if _, ok := typeExpr.(*ast.StructType); ok {
+		return true
+	}
+	typeSpec, _ := p.findTypeSpec(file, typeExpr)
+	if typeSpec == nil {
+		return false
+	}
+	_, ok := typeSpec.Type.(*ast.StructType)
+	return ok
+}
+
+// derefType returns the element type of a pointer type, typeExpr otherwise.
+func derefType(typeExpr ast.Expr) ast.Expr {
+	if star, ok := typeExpr.(*ast.StarExpr); ok {
+		return star.X
+	}
+	return typeExpr
+}
+
+// schemaParameter returns the parameter of a primitive schema, or of an array of primitives, in the given
+// location. Objects can only be sent in a body.
+func schemaParameter(in, name string, schema spec.Schema, required bool) (spec.Parameter, bool) {
+	if schema.Ref.String() != "" || len(schema.Type) == 0 || schema.Type.Contains("object") {
+		return spec.Parameter{}, false
+	}
+	if schema.Type.Contains("file") && in != "formData" {
+		return spec.Parameter{}, false
+	}
+	param := createParameter(in, schema.Description, name, schema.Type[0], required)
+	param.Format = schema.Format
+	param.Default = schema.Default
+	param.CommonValidations = spec.CommonValidations{
+		Maximum:          schema.Maximum,
+		ExclusiveMaximum: schema.ExclusiveMaximum,
+		Minimum:          schema.Minimum,
+		ExclusiveMinimum: schema.ExclusiveMinimum,
+		MaxLength:        schema.MaxLength,
+		MinLength:        schema.MinLength,
+		Pattern:          schema.Pattern,
+		MaxItems:         schema.MaxItems,
+		MinItems:         schema.MinItems,
+		UniqueItems:      schema.UniqueItems,
+		MultipleOf:       schema.MultipleOf,
+		Enum:             schema.Enum,
+	}
+	if schema.Type.Contains("array") {
+		if schema.Items == nil || schema.Items.Schema == nil {
+			return spec.Parameter{}, false
+		}
+		items := schema.Items.Schema
+		if items.Ref.String() != "" || len(items.Type) == 0 || items.Type.Contains("object") || items.Type.Contains("array") {
+			return spec.Parameter{}, false
+		}
+		param.Items = &spec.Items{
+			SimpleSchema: spec.SimpleSchema{Type: items.Type[0], Format: items.Format},
+			CommonValidations: spec.CommonValidations{
+				Maximum:   items.Maximum,
+				Minimum:   items.Minimum,
+				MaxLength: items.MaxLength,
+				MinLength: items.MinLength,
+				Pattern:   items.Pattern,
+				Enum:      items.Enum,
+			},
+		}
+		if in == "query" || in == "formData" {
+			param.CollectionFormat = "multi" // gin binds the repeated values of a key
+		}
+	}
+	return param, true
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseParamComment(t *testing.T) {
+	tests := []struct {
+		comment string
+		param   string // the parameter as JSON, empty when the comment is an error
+		err     string
+	}{
+		{
+			comment: `status query string false "status" Enums(active, closed) Default(active)`,
+			param:   `{"enum":["active","closed"],"type":"string","default":"active","description":"status","name":"status","in":"query"}`,
+		},
+		{
+			comment: `limit query int false "page size" Minimum(1) Maximum(100) Default(20)`,
+			param:   `{"maximum":100,"minimum":1,"type":"integer","format":"int64","default":20,"description":"page size","name":"limit","in":"query"}`,
+		},
+		{
+			comment: `name query string true "name" MinLength(1) MaxLength(64)`,
+			param:   `{"maxLength":64,"minLength":1,"type":"string","description":"name","name":"name","in":"query","required":true}`,
+		},
+		{
+			comment: `since query string false "since" Format(date-time)`,
+			param:   `{"type":"string","format":"date-time","description":"since","name":"since","in":"query"}`,
+		},
+		{
+			comment: `ids query []int false "ids" CollectionFormat(multi) Enums(1,2,3) Default(1,2)`,
+			param:   `{"type":"array","items":{"enum":[1,2,3],"type":"integer","format":"int64"},"collectionFormat":"multi","default":[1,2],"description":"ids","name":"ids","in":"query"}`,
+		},
+		{
+			comment: `tags query []string false "tags"`,
+			param:   `{"type":"array","items":{"type":"string"},"description":"tags","name":"tags","in":"query"}`,
+		},
+		{
+			comment: `picture formData file true "the picture"`,
+			param:   `{"type":"file","description":"the picture","name":"picture","in":"formData","required":true}`,
+		},
+		{
+			comment: `picture query file true "the picture"`,
+			err:     `unknown query param type "file"`,
+		},
+		{
+			comment: `session cookie string true "the session" Default(x)`,
+			err:     `cookie params take no attributes`,
+		},
+		{
+			comment: `limit query int false "page size" Minimum(one)`,
+			err:     `Minimum(one) of param "limit" is not a number`,
+		},
+		{
+			comment: `limit query int false "page size" Default(many)`,
+			err:     `"many" is not a valid integer`,
+		},
+		{
+			comment: `name query string false "name" CollectionFormat(multi)`,
+			err:     `CollectionFormat(multi) of param "name" needs an array type`,
+		},
+		{
+			comment: `ids query []int false "ids" CollectionFormat(commas)`,
+			err:     `unknown collection format "commas" of param "ids"`,
+		},
+		{
+			comment: `name query string false "name" Pattern(^a)`,
+			err:     `unknown attribute Pattern(^a) of param "name"`,
+		},
+		{
+			comment: `name query Pet false "name"`,
+			err:     `unknown query param type "Pet"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.comment, func(t *testing.T) {
+			operation := NewOperation()
+			err := operation.ParseParamComment(test.comment)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("got error %v, want one containing %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(operation.Parameters) != 1 {
+				t.Fatalf("got %d parameters, want 1", len(operation.Parameters))
+			}
+			b, err := json.Marshal(operation.Parameters[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != test.param {
+				t.Errorf("got param %s, want %s", b, test.param)
+			}
+		})
+	}
+}
+
+func TestParseFormAndCookieParams(t *testing.T) {
+	tests := []struct {
+		name     string
+		comments []string
+		consumes []string
+		cookie   string // the Cookie header parameter as JSON
+	}{
+		{
+			name:     "form without files",
+			comments: []string{`name formData string true "name"`, `age formData int false "age"`},
+			consumes: []string{"application/x-www-form-urlencoded"},
+		},
+		{
+			name:     "form with a file",
+			comments: []string{`name formData string true "name"`, `picture formData file true "picture"`},
+			consumes: []string{"multipart/form-data"},
+		},
+		{
+			name:     "cookies share the Cookie header",
+			comments: []string{`session cookie string true "the session"`, `lang cookie string false "the language"`},
+			cookie:   `{"type":"string","x-cookies":[{"name":"session","description":"the session","required":true},{"name":"lang","description":"the language"}],"description":"session: the session; lang: the language","name":"Cookie","in":"header","required":true}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			operation := NewOperation()
+			for _, comment := range test.comments {
+				if err := operation.ParseParamComment(comment); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := formConsumes(operation.Parameters); !reflect.DeepEqual(got, test.consumes) {
+				t.Errorf("got consumes %q, want %q", got, test.consumes)
+			}
+			cookie := ""
+			for _, param := range operation.Parameters {
+				if param.In == "header" && param.Name == "Cookie" {
+					b, err := json.Marshal(param)
+					if err != nil {
+						t.Fatal(err)
+					}
+					cookie = string(b)
+				}
+			}
+			if cookie != test.cookie {
+				t.Errorf("got Cookie header %s, want %s", cookie, test.cookie)
+			}
+		})
+	}
+}
@@ -0,0 +1,593 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// basicTypes maps the Go builtin types to swagger [type, format].
+var basicTypes = map[string][2]string{
+	"bool":       {"boolean", ""},
+	"string":     {"string", ""},
+	"int":        {"integer", "int64"},
+	"int8":       {"integer", "int32"},
+	"int16":      {"integer", "int32"},
+	"int32":      {"integer", "int32"},
+	"int64":      {"integer", "int64"},
+	"uint":       {"integer", "int64"},
+	"uint8":      {"integer", "int32"},
+	"uint16":     {"integer", "int32"},
+	"uint32":     {"integer", "int64"},
+	"uint64":     {"integer", "int64"},
+	"uintptr":    {"integer", "int64"},
+	"byte":       {"integer", "int32"},
+	"rune":       {"integer", "int32"},
+	"float32":    {"number", "float"},
+	"float64":    {"number", "double"},
+	"complex64":  {"string", ""},
+	"complex128": {"string", ""},
+	"error":      {"string", ""},
+}
+
+// basicSchema returns the schema of a Go builtin type.
+func basicSchema(typeName string) (spec.Schema, bool) {
+	typeFormat, ok := basicTypes[typeName]
+	if !ok {
+		return spec.Schema{}, false
+	}
+	return spec.Schema{
+		SchemaProps: spec.SchemaProps{Type: []string{typeFormat[0]}, Format: typeFormat[1]},
+	}, true
+}
+
+// wellKnownTypes maps types of the standard library and common drivers to their JSON representation.
+// NewParser copies them into TypeMappings, so -typeMapping can override every entry.
+var wellKnownTypes = map[string]spec.Schema{
+	"time.Time":       {SchemaProps: spec.SchemaProps{Type: []string{"string"}, Format: "date-time"}},
+	"time.Duration":   {SchemaProps: spec.SchemaProps{Type: []string{"integer"}, Format: "int64"}},
+	"json.RawMessage": {SchemaProps: spec.SchemaProps{Type: []string{"object"}}},
+	"json.Number":     {SchemaProps: spec.SchemaProps{Type: []string{"number"}}},
+	"url.URL":         {SchemaProps: spec.SchemaProps{Type: []string{"string"}, Format: "uri"}},
+	"bson.ObjectId":   {SchemaProps: spec.SchemaProps{Type: []string{"string"}, Pattern: "^[0-9a-fA-F]{24}$"}},
+	"bson.M":          {SchemaProps: spec.SchemaProps{Type: []string{"object"}}},
+	"bson.D":          {SchemaProps: spec.SchemaProps{Type: []string{"object"}}},
+	"uuid.UUID":       {SchemaProps: spec.SchemaProps{Type: []string{"string"}, Format: "uuid"}},
+	"sql.NullString":  nullableSchema("string", ""),
+	"sql.NullBool":    nullableSchema("boolean", ""),
+	"sql.NullByte":    nullableSchema("integer", "int32"),
+	"sql.NullInt16":   nullableSchema("integer", "int32"),
+	"sql.NullInt32":   nullableSchema("integer", "int32"),
+	"sql.NullInt64":   nullableSchema("integer", "int64"),
+	"sql.NullFloat64": nullableSchema("number", "double"),
+	"sql.NullTime":    nullableSchema("string", "date-time"),
+}
+
+// nullableSchema returns a x-nullable schema of the given type and format.
+func nullableSchema(swaggerType, format string) spec.Schema {
+	return spec.Schema{
+		VendorExtensible: spec.VendorExtensible{Extensions: spec.Extensions{"x-nullable": true}},
+		SchemaProps:      spec.SchemaProps{Type: []string{swaggerType}, Format: format},
+	}
+}
+
+// MapType documents every field of the named type, e.g. decimal.Decimal, with the given schema
+// instead of resolving the type itself.
+func (p *Parser) MapType(typeName string, schema spec.Schema) {
+	p.TypeMappings[typeName] = schema
+}
+
+// ParseTypeMapping parses comma separated `name=type[/format]` mappings such as
+// `decimal.Decimal=number/double,bson.ObjectId=string` and adds them to TypeMappings.
+func (p *Parser) ParseTypeMapping(mappings string) error {
+	for _, mapping := range strings.Split(mappings, ",") {
+		if strings.TrimSpace(mapping) == "" {
+			continue
+		}
+		parts := strings.SplitN(mapping, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return fmt.Errorf("Can not parse type mapping \"%s\", expect name=type[/format].", mapping)
+		}
+		typeFormat := strings.SplitN(strings.TrimSpace(parts[1]), "/", 2)
+		schema := spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{typeFormat[0]}}}
+		if len(typeFormat) == 2 {
+			schema.Format = typeFormat[1]
+		}
+		p.MapType(strings.TrimSpace(parts[0]), schema)
+	}
+	return nil
+}
+
+// qualifiedTypeName returns the package qualified name of a named type expression in file, like model.Pets.
+func qualifiedTypeName(file *ast.File, typeExpr ast.Expr) string {
+	switch expr := typeExpr.(type) {
+	case *ast.Ident:
+		return file.Name.Name + "." + expr.Name
+	case *ast.SelectorExpr:
+		if pkg, ok := expr.X.(*ast.Ident); ok {
+			return importedPackage(file, pkg.Name) + "." + expr.Sel.Name
+		}
+	}
+	return ""
+}
+
+// parseTypeSchema returns the schema for the given type expression declared in file.
+func (p *Parser) parseTypeSchema(file *ast.File, typeExpr ast.Expr) spec.Schema {
+	if schema, ok := p.TypeMappings[qualifiedTypeName(file, typeExpr)]; ok {
+		return schema
+	}
+	switch expr := typeExpr.(type) {
+	case *ast.StarExpr: // pointers are documented as their element type
+		schema := p.parseTypeSchema(file, expr.X)
+		extensions := spec.Extensions{"x-nullable": true} // never modify the extensions of a shared mapping
+		for key, value := range schema.Extensions {
+			extensions[key] = value
+		}
+		schema.Extensions = extensions
+		return schema
+	case *ast.StructType:
+		return p.parseStructSchema(file, expr)
+	case *ast.Ident, *ast.SelectorExpr:
+		if typeSpec, _ := p.findTypeSpec(file, expr); typeSpec != nil {
+			if _, ok := p.definitionName(typeSpec); !ok && p.isPrimitiveType(typeSpec) {
+				return p.parseNamedTypeSchema(typeSpec)
+			}
+			return refSchema(p.registerType(typeSpec))
+		}
+		if ident, ok := expr.(*ast.Ident); ok {
+			if schema, ok := basicSchema(ident.Name); ok {
+				return schema
+			}
+		}
+	case *ast.ArrayType:
+		if ident, ok := expr.Elt.(*ast.Ident); ok && expr.Len == nil && (ident.Name == "byte" || ident.Name == "uint8") {
+			// encoding/json encodes []byte as a base64 string
+			return spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"string"}, Format: "byte"}}
+		}
+		items := p.parseTypeSchema(file, expr.Elt)
+		schema := spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Type:  []string{"array"},
+				Items: &spec.SchemaOrArray{Schema: &items},
+			},
+		}
+		if expr.Len != nil { // arrays have a fixed size
+			if size, ok := constant.Int64Val(constant.ToInt(p.evalConst(file.Name.Name, expr.Len, 0))); ok {
+				schema.MinItems = &size
+				schema.MaxItems = &size
+			}
+		}
+		return schema
+	case *ast.MapType:
+		values := p.parseTypeSchema(file, expr.Value)
+		return spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Type:                 []string{"object"},
+				AdditionalProperties: &spec.SchemaOrBool{Allows: true, Schema: &values},
+			},
+		}
+	}
+	name := getPropertyName(typeExpr)
+	if name == "" { // funcs and channels, that encoding/json can not encode
+		p.report(typeExpr.Pos(), SeverityWarning, CodeUnsupportedType, "type %s is documented as an object", types.ExprString(typeExpr))
+		name = "object"
+	}
+	return spec.Schema{
+		SchemaProps: spec.SchemaProps{Type: []string{name}},
+	}
+}
+
+// parseNamedTypeSchema returns the schema of the type declared by typeSpec, including the enum of its constants.
+func (p *Parser) parseNamedTypeSchema(typeSpec *ast.TypeSpec) spec.Schema {
+	file := p.typeFiles[typeSpec]
+	p.expanding[typeSpec] = true
+	schema := p.parseTypeSchema(file, typeSpec.Type)
+	delete(p.expanding, typeSpec)
+	return p.withEnum(schema, file.Name.Name+"."+typeSpec.Name.Name)
+}
+
+// isPrimitiveType reports whether typeSpec is a named builtin or mapped type, such as `type Status string`.
+func (p *Parser) isPrimitiveType(typeSpec *ast.TypeSpec) bool {
+	file := p.typeFiles[typeSpec]
+	if _, ok := p.TypeMappings[qualifiedTypeName(file, typeSpec.Type)]; ok {
+		return true
+	}
+	if underlying, _ := p.findTypeSpec(file, typeSpec.Type); underlying != nil {
+		if _, ok := typeSpec.Type.(*ast.StarExpr); ok || underlying == typeSpec {
+			return false
+		}
+		return p.isPrimitiveType(underlying)
+	}
+	if ident, ok := typeSpec.Type.(*ast.Ident); ok {
+		_, ok = basicTypes[ident.Name]
+		return ok
+	}
+	return false
+}
+
+// parseStructSchema returns the object schema of a struct type declared in file.
+// Embedded @def models are composed with allOf, other embedded structs are flattened into the properties.
+func (p *Parser) parseStructSchema(file *ast.File, structType *ast.StructType) spec.Schema {
+	properties := make(map[string]spec.Schema)
+	var required []string
+	var allOf []spec.Schema
+
+	// promoted fields never override the fields declared on the struct itself
+	promoted := make(map[string]spec.Schema)
+	var promotedRequired []string
+
+	for _, field := range structType.Fields.List {
+		tag := parseJSONTag(field)
+		if tag.Ignore {
+			continue
+		}
+		if len(field.Names) == 0 && tag.Name == "" {
+			typeSpec, typeFile := p.findTypeSpec(file, field.Type)
+			if typeSpec == nil {
+				continue
+			}
+			if _, ok := typeSpec.Type.(*ast.StructType); !ok && !ast.IsExported(typeSpec.Name.Name) {
+				continue // encoding/json drops embedded unexported non-struct types
+			}
+			if refTypeName, ok := p.definitionName(typeSpec); ok {
+				p.registerTypes[refTypeName] = typeSpec
+				allOf = append(allOf, refSchema(refTypeName))
+				continue
+			}
+			if p.expanding[typeSpec] { // a struct embedding itself, directly or not, is referenced
+				allOf = append(allOf, refSchema(p.registerType(typeSpec)))
+				continue
+			}
+			p.expanding[typeSpec] = true
+			embedded := p.parseTypeSchema(typeFile, typeSpec.Type)
+			delete(p.expanding, typeSpec)
+			for name, property := range embedded.Properties {
+				promoted[name] = property
+			}
+			if _, ok := field.Type.(*ast.StarExpr); !ok { // the fields of a nil embedded pointer are left out
+				promotedRequired = append(promotedRequired, embedded.Required...)
+			}
+			allOf = append(allOf, embedded.AllOf...)
+			continue
+		}
+
+		property := p.parseTypeSchema(file, field.Type)
+		if tag.AsString && !property.Type.Contains("array") && !property.Type.Contains("object") {
+			property.Type = []string{"string"}
+		}
+		if (property.Type.Contains("array") || property.Type.Contains("object")) && field.Tag != nil {
+			re := regexp.MustCompile(`swag\:\"(\w+)\"`)
+			s := re.FindStringSubmatch(field.Tag.Value)
+			if len(s) == 2 { // an explicit swag tag names the items or values definition
+				items := refSchema(s[1])
+				if property.Type.Contains("array") {
+					property.Items = &spec.SchemaOrArray{Schema: &items}
+				} else {
+					property.AdditionalProperties = &spec.SchemaOrBool{Allows: true, Schema: &items}
+				}
+			}
+		}
+		// fields are required unless omitempty, or when their binding tag requires them; constraint rules
+		// like max=64 do not change it
+		isRequired := !tag.OmitEmpty
+		if rules, ok := parseBindingTag(field); ok {
+			isRequired = applyBindingRules(&property, rules) || isRequired
+		}
+		if property.Ref.String() == "" { // siblings of $ref are ignored
+			applyFieldDoc(&property, field)
+		}
+
+		if len(field.Names) == 0 { // embedded field with a json name is a regular property
+			properties[tag.Name] = property
+			if isRequired {
+				required = append(required, tag.Name)
+			}
+			continue
+		}
+		for _, fieldName := range field.Names {
+			if !fieldName.IsExported() {
+				continue
+			}
+			name := tag.Name
+			if name == "" {
+				name = p.propertyName(fieldName.Name)
+			}
+			properties[name] = property
+			if isRequired {
+				required = append(required, name)
+			}
+		}
+	}
+
+	for _, name := range promotedRequired {
+		if _, ok := properties[name]; !ok {
+			required = append(required, name)
+		}
+	}
+	for name, property := range promoted {
+		if _, ok := properties[name]; !ok {
+			properties[name] = property
+		}
+	}
+
+	schema := spec.Schema{
+		SchemaProps: spec.SchemaProps{
+			Type:       []string{"object"},
+			Properties: properties,
+			Required:   required,
+		},
+	}
+	if len(allOf) == 0 {
+		return schema
+	}
+	return spec.Schema{
+		SchemaProps: spec.SchemaProps{
+			AllOf: append(allOf, schema),
+		},
+	}
+}
+
+// definitionRef returns a reference to the named definition, an error when a name written in a comment
+// can not be part of a URL.
+func definitionRef(refTypeName string) (spec.Ref, error) {
+	ref, err := spec.NewRef("#/definitions/" + refTypeName)
+	if err != nil {
+		return spec.Ref{}, fmt.Errorf("invalid definition name \"%s\": %v", refTypeName, err)
+	}
+	return ref, nil
+}
+
+// refSchema returns a schema referencing the named definition, a Go type or a @def name that passed
+// definitionRef.
+func refSchema(refTypeName string) spec.Schema {
+	ref, _ := definitionRef(refTypeName)
+	return spec.Schema{SchemaProps: spec.SchemaProps{Ref: ref}}
+}
+
+// registerType queues typeSpec for ParseDefinitions and returns its definition name.
+func (p *Parser) registerType(typeSpec *ast.TypeSpec) string {
+	refTypeName, ok := p.definitionName(typeSpec)
+	if !ok {
+		refTypeName = p.typeFiles[typeSpec].Name.Name + "." + typeSpec.Name.Name
+	}
+	p.registerTypes[refTypeName] = typeSpec
+	return refTypeName
+}
+
+// findTypeSpec returns the type spec named by typeExpr in file and the file declaring it, nil if it is unknown.
+func (p *Parser) findTypeSpec(file *ast.File, typeExpr ast.Expr) (*ast.TypeSpec, *ast.File) {
+	var typeSpec *ast.TypeSpec
+	switch expr := typeExpr.(type) {
+	case *ast.StarExpr:
+		return p.findTypeSpec(file, expr.X)
+	case *ast.Ident:
+		typeSpec = p.TypeDefinitions[file.Name.Name][expr.Name]
+	case *ast.SelectorExpr:
+		if pkg, ok := expr.X.(*ast.Ident); ok {
+			typeSpec = p.TypeDefinitions[importedPackage(file, pkg.Name)][expr.Sel.Name]
+		}
+	}
+	if typeSpec == nil {
+		return nil, nil
+	}
+	return typeSpec, p.typeFiles[typeSpec]
+}
+
+// findTypeByName returns the type spec for a type name written in a comment of file: a @def name,
+// a qualified name like model.Pets or a type of the file's own package. Unqualified names that are
+// declared in exactly one package are found as well. It returns nil if the type is unknown.
+func (p *Parser) findTypeByName(file *ast.File, typeName string) *ast.TypeSpec {
+	if typeSpec, ok := p.Definitions[typeName]; ok {
+		return typeSpec
+	}
+	if i := strings.LastIndex(typeName, "."); i > 0 {
+		pkgName := typeName[:i]
+		if file != nil {
+			pkgName = importedPackage(file, pkgName)
+		}
+		return p.TypeDefinitions[pkgName][typeName[i+1:]]
+	}
+	if file != nil {
+		if typeSpec, ok := p.TypeDefinitions[file.Name.Name][typeName]; ok {
+			return typeSpec
+		}
+	}
+	var found *ast.TypeSpec
+	for _, types := range p.TypeDefinitions {
+		if typeSpec, ok := types[typeName]; ok {
+			if found != nil {
+				return nil
+			}
+			found = typeSpec
+		}
+	}
+	return found
+}
+
+// importedPackage returns the name of the package imported as localName in file.
+func importedPackage(file *ast.File, localName string) string {
+	for _, importSpec := range file.Imports {
+		importPath, err := strconv.Unquote(importSpec.Path.Value)
+		if err != nil {
+			continue
+		}
+		// gopkg.in/mgo.v2 declares package mgo
+		pkgName := strings.SplitN(path.Base(importPath), ".", 2)[0]
+		if importSpec.Name != nil {
+			if importSpec.Name.Name == localName {
+				return pkgName
+			}
+			continue
+		}
+		if pkgName == localName {
+			return pkgName
+		}
+	}
+	return localName
+}
+
+// definitionName returns the @def name of typeSpec if it is a registered definition.
+func (p *Parser) definitionName(typeSpec *ast.TypeSpec) (string, bool) {
+	for refTypeName, definition := range p.Definitions {
+		if definition == typeSpec {
+			return refTypeName, true
+		}
+	}
+	return "", false
+}
+
+// getPropertyName returns the swagger type name for a type expression without a more specific schema.
+// allowedValues: array, boolean, integer, null, number, object, string, or "" for unsupported types
+func getPropertyName(typeExpr ast.Expr) string {
+	switch expr := typeExpr.(type) {
+	case *ast.SelectorExpr: // types of packages that were not scanned are most likely structs
+		return "object"
+	case *ast.Ident:
+		if typeFormat, ok := basicTypes[expr.Name]; ok {
+			return typeFormat[0]
+		}
+		return "object"
+	case *ast.StarExpr:
+		return getPropertyName(expr.X)
+	case *ast.ArrayType: // if array
+		return "array"
+	case *ast.MapType, *ast.StructType, *ast.InterfaceType: // if map, struct or interface{}
+		return "object"
+	}
+	return ""
+}
+
+// lookupTag returns the value of the given key in the struct tag of field.
+func lookupTag(field *ast.Field, key string) (string, bool) {
+	if field.Tag == nil {
+		return "", false
+	}
+	tagValue, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return "", false
+	}
+	return reflect.StructTag(tagValue).Lookup(key)
+}
+
+// applyFieldDoc sets the description of schema from the doc or line comment of field and its
+// example, default and format from the struct tags of the same name.
+func applyFieldDoc(schema *spec.Schema, field *ast.Field) {
+	if field.Doc != nil {
+		schema.Description = strings.TrimSpace(field.Doc.Text())
+	} else if field.Comment != nil {
+		schema.Description = strings.TrimSpace(field.Comment.Text())
+	}
+	if format, ok := lookupTag(field, "format"); ok {
+		schema.Format = format
+	}
+	if example, ok := lookupTag(field, "example"); ok {
+		schema.Example = exampleValue(schema, example)
+	}
+	if defaultValue, ok := lookupTag(field, "default"); ok {
+		schema.Default = exampleValue(schema, defaultValue)
+	}
+}
+
+// exampleValue converts the text of an example or default to a value of the schema type.
+// Array values are comma separated, object values are JSON. Text that does not fit the type is kept as is.
+func exampleValue(schema *spec.Schema, text string) interface{} {
+	switch {
+	case schema.Type.Contains("array"):
+		items := &spec.Schema{}
+		if schema.Items != nil && schema.Items.Schema != nil {
+			items = schema.Items.Schema
+		}
+		values := []interface{}{}
+		for _, item := range strings.Split(text, ",") {
+			values = append(values, exampleValue(items, strings.TrimSpace(item)))
+		}
+		return values
+	case schema.Type.Contains("object"):
+		var value interface{}
+		if err := json.Unmarshal([]byte(text), &value); err == nil {
+			return value
+		}
+	default:
+		if value, ok := typedValue(schema, text); ok {
+			return value
+		}
+	}
+	return text
+}
+
+// jsonTag holds the encoding/json options of a struct field.
+type jsonTag struct {
+	Name      string
+	Ignore    bool
+	OmitEmpty bool
+	AsString  bool
+}
+
+// parseJSONTag returns the json tag options for the given field, the zero value if it has none.
+func parseJSONTag(field *ast.Field) jsonTag {
+	var tag jsonTag
+	if field.Tag == nil {
+		return tag
+	}
+	tagValue, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return tag
+	}
+	value, ok := reflect.StructTag(tagValue).Lookup("json")
+	if !ok {
+		return tag
+	}
+	if value == "-" {
+		tag.Ignore = true
+		return tag
+	}
+	options := strings.Split(value, ",")
+	tag.Name = options[0]
+	for _, option := range options[1:] {
+		switch option {
+		case "omitempty":
+			tag.OmitEmpty = true
+		case "string":
+			tag.AsString = true
+		}
+	}
+	return tag
+}
+
+// propertyName returns the property name for a field without json tag according to PropNamingStrategy.
+func (p *Parser) propertyName(fieldName string) string {
+	switch p.PropNamingStrategy {
+	case PascalCase:
+		return fieldName
+	case CamelCase:
+		return strings.ToLower(fieldName[:1]) + fieldName[1:]
+	default:
+		return snakeString(fieldName)
+	}
+}
+
+func snakeString(s string) string {
+	data := make([]byte, 0, len(s)*2)
+	j := false
+	num := len(s)
+	for i := 0; i < num; i++ {
+		d := s[i]
+		if i > 0 && d >= 'A' && d <= 'Z' && j {
+			data = append(data, '_')
+		}
+		if d != '_' {
+			j = true
+		}
+		data = append(data, d)
+	}
+	return strings.ToLower(string(data[:]))
+}
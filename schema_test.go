@@ -0,0 +1,473 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+// fixtureDefinition parses src as main.go and returns the sorted properties and required properties of
+// the @def Model definition.
+func fixtureDefinition(t *testing.T, src string) (properties, required []string) {
+	t.Helper()
+	schema := fixtureModel(t, NewParser(), map[string]string{"main.go": src})
+	for name := range schema.Properties {
+		properties = append(properties, name)
+	}
+	sort.Strings(properties)
+	required = append(required, schema.Required...)
+	sort.Strings(required)
+	return properties, required
+}
+
+// fixtureModel parses files with parser and returns the @def Model definition.
+func fixtureModel(t *testing.T, parser *Parser, files map[string]string) spec.Schema {
+	t.Helper()
+	parseFixtureWith(t, parser, files)
+	if len(parser.Diagnostics) > 0 {
+		t.Fatalf("unexpected diagnostics: %v", parser.Diagnostics)
+	}
+	schema, ok := parser.swagger.Definitions["Model"]
+	if !ok {
+		t.Fatal("Model is not defined")
+	}
+	return schema
+}
+
+// describeSchemas returns the JSON of each schema by its name, sorted.
+func describeSchemas(t *testing.T, schemas map[string]spec.Schema) []string {
+	t.Helper()
+	var described []string
+	for name, schema := range schemas {
+		b, err := json.Marshal(schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		described = append(described, name+" "+string(b))
+	}
+	sort.Strings(described)
+	return described
+}
+
+func TestParseStructSchemaEmbedded(t *testing.T) {
+	properties, required := fixtureDefinition(t, `package main
+
+func main() {}
+
+// @def Model
+type Model struct {
+	Base
+	base2
+	*base3
+	myInt
+	Name string `+"`json:\"name\"`"+`
+}
+
+type Base struct {
+	ID int `+"`json:\"id\"`"+`
+}
+
+type base2 struct {
+	Hidden string `+"`json:\"hidden\"`"+`
+}
+
+type base3 struct {
+	Skipped string `+"`json:\"skipped\"`"+`
+}
+
+type myInt int
+`)
+	// encoding/json promotes the fields of embedded unexported structs, pointers included, and drops
+	// embedded unexported non-struct types
+	if want := []string{"hidden", "id", "name", "skipped"}; !reflect.DeepEqual(properties, want) {
+		t.Errorf("got properties %q, want %q", properties, want)
+	}
+	// the fields of an embedded pointer are left out when it is nil
+	if want := []string{"hidden", "id", "name"}; !reflect.DeepEqual(required, want) {
+		t.Errorf("got required %q, want %q", required, want)
+	}
+}
+
+func TestParseStructSchemaRecursiveEmbedding(t *testing.T) {
+	parser := parseFixture(t, map[string]string{"main.go": `package main
+
+func main() {}
+
+// @def Model
+type Model struct {
+	Node Node ` + "`json:\"node\"`" + `
+	A    A    ` + "`json:\"a\"`" + `
+}
+
+type Node struct {
+	*Node
+	Val int ` + "`json:\"val\"`" + `
+}
+
+type A struct {
+	*B
+	X int ` + "`json:\"x\"`" + `
+}
+
+type B struct {
+	*A
+	Y int ` + "`json:\"y\"`" + `
+}
+`})
+	for _, name := range []string{"main.Node", "main.A"} {
+		schema, ok := parser.swagger.Definitions[name]
+		if !ok {
+			t.Fatalf("%s is not defined", name)
+		}
+		// the struct being expanded is referenced instead of embedded again
+		if len(schema.AllOf) == 0 || schema.AllOf[0].Ref.String() != "#/definitions/"+name {
+			t.Errorf("%s does not reference itself: %+v", name, schema)
+		}
+	}
+}
+
+func TestParseStructSchemaRequired(t *testing.T) {
+	_, required := fixtureDefinition(t, `package main
+
+func main() {}
+
+// @def Model
+type Model struct {
+	Name     string `+"`json:\"name\"`"+`
+	Email    string `+"`json:\"email\" binding:\"max=64\"`"+`
+	Note     string `+"`json:\"note,omitempty\"`"+`
+	Nickname string `+"`json:\"nickname,omitempty\" binding:\"max=16\"`"+`
+	Token    string `+"`json:\"token,omitempty\" binding:\"required\"`"+`
+}
+`)
+	// omitempty makes a field optional unless its binding tag requires it, constraint rules change nothing
+	if want := []string{"email", "name", "token"}; !reflect.DeepEqual(required, want) {
+		t.Errorf("got required %q, want %q", required, want)
+	}
+}
+
+func TestParseArraySchemaSize(t *testing.T) {
+	parser := parseFixture(t, map[string]string{"main.go": `package main
+
+func main() {}
+
+const size = 4.0
+
+// @def Model
+type Model struct {
+	Fixed  [size]int ` + "`json:\"fixed\"`" + `
+	Sized  [2]int    ` + "`json:\"sized\"`" + `
+	Sliced []int     ` + "`json:\"sliced\"`" + `
+}
+`})
+	properties := parser.swagger.Definitions["Model"].Properties
+	for name, want := range map[string]int64{"fixed": 4, "sized": 2, "sliced": -1} {
+		got := int64(-1)
+		if property := properties[name]; property.MinItems != nil && property.MaxItems != nil && *property.MinItems == *property.MaxItems {
+			got = *property.MinItems
+		}
+		if got != want {
+			t.Errorf("got %s size %d, want %d", name, got, want)
+		}
+	}
+}
+
+func TestParseStructSchemaJSONTags(t *testing.T) {
+	const src = `package main
+
+func main() {}
+
+// @def Model
+type Model struct {
+	PetID     int    ` + "`json:\"petId\"`" + `
+	Secret    string ` + "`json:\"-\"`" + `
+	Dash      string ` + "`json:\"-,\"`" + `
+	Note      string ` + "`json:\",omitempty\"`" + `
+	Count     int64  ` + "`json:\"count,string\"`" + `
+	FirstName string
+	internal  string
+}
+`
+	tests := []struct {
+		propNaming string
+		want       []string
+		required   []string
+	}{
+		{
+			propNaming: SnakeCase,
+			want: []string{
+				`- {"type":"string"}`,
+				`count {"type":"string","format":"int64"}`,
+				`first_name {"type":"string"}`,
+				`note {"type":"string"}`,
+				`petId {"type":"integer","format":"int64"}`,
+			},
+			required: []string{"-", "count", "first_name", "petId"},
+		},
+		{
+			propNaming: CamelCase,
+			want: []string{
+				`- {"type":"string"}`,
+				`count {"type":"string","format":"int64"}`,
+				`firstName {"type":"string"}`,
+				`note {"type":"string"}`,
+				`petId {"type":"integer","format":"int64"}`,
+			},
+			required: []string{"-", "count", "firstName", "petId"},
+		},
+		{
+			propNaming: PascalCase,
+			want: []string{
+				`- {"type":"string"}`,
+				`FirstName {"type":"string"}`,
+				`Note {"type":"string"}`,
+				`count {"type":"string","format":"int64"}`,
+				`petId {"type":"integer","format":"int64"}`,
+			},
+			required: []string{"-", "FirstName", "count", "petId"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.propNaming, func(t *testing.T) {
+			parser := NewParser()
+			parser.PropNamingStrategy = test.propNaming
+			schema := fixtureModel(t, parser, map[string]string{"main.go": src})
+			if got := describeSchemas(t, schema.Properties); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got properties %q, want %q", got, test.want)
+			}
+			required := append([]string{}, schema.Required...)
+			sort.Strings(required)
+			if !reflect.DeepEqual(required, test.required) {
+				t.Errorf("got required %q, want %q", required, test.required)
+			}
+		})
+	}
+}
+
+func TestDiscoverDefinitions(t *testing.T) {
+	parser := parseFixture(t, map[string]string{
+		"main.go": `package main
+
+import (
+	"github.com/gin-gonic/gin"
+	m "example.com/app/model"
+)
+
+func main() {}
+
+// @Param pet body m.Pet true "the pet"
+// @Success 200 {array} m.Pet "ok"
+// @Router /pets [post]
+func createPet(c *gin.Context) {}
+`,
+		"model/model.go": `package model
+
+import "example.com/app/model/owner"
+
+type Pet struct {
+	Name  string       ` + "`json:\"name\"`" + `
+	Tags  []Tag        ` + "`json:\"tags\"`" + `
+	Owner *owner.Owner ` + "`json:\"owner\"`" + `
+}
+
+type Tag struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+`,
+		"model/owner/owner.go": `package owner
+
+// @def Person
+type Owner struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+type Unused struct{}
+`,
+	})
+	if len(parser.Diagnostics) > 0 {
+		t.Fatalf("unexpected diagnostics: %v", parser.Diagnostics)
+	}
+	// referenced types are found through the imports, transitively, @def only renames them
+	want := []string{
+		`Person {"type":"object","required":["name"],"properties":{"name":{"type":"string"}}}`,
+		`model.Pet {"type":"object","required":["name","tags","owner"],"properties":{"name":{"type":"string"},"owner":{"x-nullable":true,"$ref":"#/definitions/Person"},"tags":{"type":"array","items":{"$ref":"#/definitions/model.Tag"}}}}`,
+		`model.Tag {"type":"object","required":["name"],"properties":{"name":{"type":"string"}}}`,
+	}
+	if got := describeSchemas(t, parser.swagger.Definitions); !reflect.DeepEqual(got, want) {
+		t.Errorf("got definitions %q, want %q", got, want)
+	}
+	operation := parser.swagger.Paths.Paths["/pets"].Post
+	if ref := operation.Parameters[0].Schema.Ref.String(); ref != "#/definitions/model.Pet" {
+		t.Errorf("got body param of %s, want #/definitions/model.Pet", ref)
+	}
+	if ref := operation.Responses.StatusCodeResponses[200].Schema.Items.Schema.Ref.String(); ref != "#/definitions/model.Pet" {
+		t.Errorf("got response items of %s, want #/definitions/model.Pet", ref)
+	}
+}
+
+func TestParseBuiltinTypes(t *testing.T) {
+	parser := NewParser()
+	if err := parser.ParseTypeMapping("decimal.Decimal=number/double, model.ID=string"); err != nil {
+		t.Fatal(err)
+	}
+	schema := fixtureModel(t, parser, map[string]string{
+		"main.go": `package main
+
+import (
+	"github.com/shopspring/decimal"
+	"example.com/app/model"
+)
+
+func main() {}
+
+// @def Model
+type Model struct {
+	Bool    bool            ` + "`json:\"bool\"`" + `
+	Int     int             ` + "`json:\"int\"`" + `
+	Int8    int8            ` + "`json:\"int8\"`" + `
+	Uint32  uint32          ` + "`json:\"uint32\"`" + `
+	Byte    byte            ` + "`json:\"byte\"`" + `
+	Rune    rune            ` + "`json:\"rune\"`" + `
+	Float32 float32         ` + "`json:\"float32\"`" + `
+	Float64 float64         ` + "`json:\"float64\"`" + `
+	Bytes   []byte          ` + "`json:\"bytes\"`" + `
+	Map     map[string]int  ` + "`json:\"map\"`" + `
+	Price   decimal.Decimal ` + "`json:\"price\"`" + `
+	ID      model.ID        ` + "`json:\"id\"`" + `
+}
+`,
+		"model/model.go": "package model\n\ntype ID struct{ hi, lo uint64 }\n",
+	})
+	want := []string{
+		`bool {"type":"boolean"}`,
+		`byte {"type":"integer","format":"int32"}`,
+		`bytes {"type":"string","format":"byte"}`,
+		`float32 {"type":"number","format":"float"}`,
+		`float64 {"type":"number","format":"double"}`,
+		`id {"type":"string"}`,
+		`int {"type":"integer","format":"int64"}`,
+		`int8 {"type":"integer","format":"int32"}`,
+		`map {"type":"object","additionalProperties":{"type":"integer","format":"int64"}}`,
+		`price {"type":"number","format":"double"}`,
+		`rune {"type":"integer","format":"int32"}`,
+		`uint32 {"type":"integer","format":"int64"}`,
+	}
+	if got := describeSchemas(t, schema.Properties); !reflect.DeepEqual(got, want) {
+		t.Errorf("got properties %q, want %q", got, want)
+	}
+	if _, ok := parser.swagger.Definitions["model.ID"]; ok {
+		t.Error("the mapped model.ID is defined")
+	}
+}
+
+func TestParseTypeMappingError(t *testing.T) {
+	for _, mapping := range []string{"decimal.Decimal", "=number", "decimal.Decimal="} {
+		if err := NewParser().ParseTypeMapping(mapping); err == nil {
+			t.Errorf("got no error for %q", mapping)
+		}
+	}
+}
+
+func TestParseWellKnownTypes(t *testing.T) {
+	const src = `package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func main() {}
+
+// @def Model
+type Model struct {
+	At      time.Time       ` + "`json:\"at\"`" + `
+	Seen    *time.Time      ` + "`json:\"seen\"`" + `
+	Timeout time.Duration   ` + "`json:\"timeout\"`" + `
+	Raw     json.RawMessage ` + "`json:\"raw\"`" + `
+	ID      bson.ObjectId   ` + "`json:\"id\"`" + `
+	Query   bson.M          ` + "`json:\"query\"`" + `
+	Count   sql.NullInt64   ` + "`json:\"count\"`" + `
+}
+`
+	tests := []struct {
+		name        string
+		typeMapping string
+		want        []string
+	}{
+		{
+			name: "builtin mappings",
+			want: []string{
+				`at {"type":"string","format":"date-time"}`,
+				`count {"type":"integer","format":"int64","x-nullable":true}`,
+				`id {"type":"string","pattern":"^[0-9a-fA-F]{24}$"}`,
+				`query {"type":"object"}`,
+				`raw {"type":"object"}`,
+				`seen {"type":"string","format":"date-time","x-nullable":true}`,
+				`timeout {"type":"integer","format":"int64"}`,
+			},
+		},
+		{
+			name:        "overridden by -typeMapping",
+			typeMapping: "time.Time=integer/int64",
+			want: []string{
+				`at {"type":"integer","format":"int64"}`,
+				`count {"type":"integer","format":"int64","x-nullable":true}`,
+				`id {"type":"string","pattern":"^[0-9a-fA-F]{24}$"}`,
+				`query {"type":"object"}`,
+				`raw {"type":"object"}`,
+				`seen {"type":"integer","format":"int64","x-nullable":true}`,
+				`timeout {"type":"integer","format":"int64"}`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parser := NewParser()
+			if err := parser.ParseTypeMapping(test.typeMapping); err != nil {
+				t.Fatal(err)
+			}
+			schema := fixtureModel(t, parser, map[string]string{"main.go": src})
+			if got := describeSchemas(t, schema.Properties); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got properties %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseFieldDoc(t *testing.T) {
+	schema := fixtureModel(t, NewParser(), map[string]string{"main.go": `package main
+
+func main() {}
+
+// @def Model
+type Model struct {
+	// Age of the pet in years.
+	Age   int      ` + "`json:\"age\" example:\"3\" default:\"1\"`" + `
+	Price float64  ` + "`json:\"price\" example:\"9.5\"`" + ` // price in euros
+	Sold  bool     ` + "`json:\"sold\" example:\"true\"`" + `
+	Tags  []int    ` + "`json:\"tags\" example:\"1, 2\"`" + `
+	Meta  struct{} ` + "`json:\"meta\" example:\"{\\\"a\\\":1}\"`" + `
+	Born  string   ` + "`json:\"born\" format:\"date\" example:\"2020-01-02\"`" + `
+	Odd   int      ` + "`json:\"odd\" example:\"many\"`" + `
+}
+`})
+	// examples and defaults are typed like the field, text that does not fit is kept as is
+	want := []string{
+		`age {"description":"Age of the pet in years.","type":"integer","format":"int64","default":1,"example":3}`,
+		`born {"type":"string","format":"date","example":"2020-01-02"}`,
+		`meta {"type":"object","example":{"a":1}}`,
+		`odd {"type":"integer","format":"int64","example":"many"}`,
+		`price {"description":"price in euros","type":"number","format":"double","example":9.5}`,
+		`sold {"type":"boolean","example":true}`,
+		`tags {"type":"array","items":{"type":"integer","format":"int64"},"example":[1,2]}`,
+	}
+	if got := describeSchemas(t, schema.Properties); !reflect.DeepEqual(got, want) {
+		t.Errorf("got properties %q, want %q", got, want)
+	}
+}
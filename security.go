@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// securitySchemes maps the @securityDefinitions attributes of the general info to new schemes.
+var securitySchemes = map[string]func() *spec.SecurityScheme{
+	"@securitydefinitions.basic":              spec.BasicAuth,
+	"@securitydefinitions.apikey":             func() *spec.SecurityScheme { return spec.APIKeyAuth("", "") },
+	"@securitydefinitions.oauth2.implicit":    func() *spec.SecurityScheme { return spec.OAuth2Implicit("") },
+	"@securitydefinitions.oauth2.password":    func() *spec.SecurityScheme { return spec.OAuth2Password("") },
+	"@securitydefinitions.oauth2.application": func() *spec.SecurityScheme { return spec.OAuth2Application("") },
+	"@securitydefinitions.oauth2.accesscode":  func() *spec.SecurityScheme { return spec.OAuth2AccessToken("", "") },
+}
+
+// securityRequirementRegexp matches a scheme of a @Security comment, like OAuth2[read,write].
+var securityRequirementRegexp = regexp.MustCompile(`^(\w+)(?:\[([^\]]*)\])?$`)
+
+// ParseSecurityAttribute sets an attribute of the general info following a @securityDefinitions line on
+// scheme: @in, @name, @authorizationUrl, @tokenUrl or @scope.<name>.
+func ParseSecurityAttribute(scheme *spec.SecurityScheme, attribute, value string) error {
+	if scheme == nil {
+		return fmt.Errorf("%s must follow a @securityDefinitions line", attribute)
+	}
+	switch strings.ToLower(attribute) {
+	case "@in":
+		scheme.In = value
+	case "@name":
+		scheme.Name = value
+	case "@authorizationurl":
+		scheme.AuthorizationURL = value
+	case "@tokenurl":
+		scheme.TokenURL = value
+	default:
+		scheme.AddScope(attribute[len("@scope."):], value)
+	}
+	return nil
+}
+
+// validateSecurityDefinitions reports the schemes missing a field their type needs, at the position of
+// their @securityDefinitions line.
+func (p *Parser) validateSecurityDefinitions(positions map[string]token.Pos) {
+	names := make([]string, 0, len(p.swagger.SecurityDefinitions))
+	for name := range p.swagger.SecurityDefinitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		scheme := p.swagger.SecurityDefinitions[name]
+		switch {
+		case scheme.Type == "apiKey" && (scheme.Name == "" || (scheme.In != "header" && scheme.In != "query")):
+			p.report(positions[name], SeverityError, CodeSecurity, "apiKey security definition %s needs @name and @in header or query", name)
+		case scheme.Type == "oauth2" && (scheme.Flow == "implicit" || scheme.Flow == "accessCode") && scheme.AuthorizationURL == "":
+			p.report(positions[name], SeverityError, CodeSecurity, "oauth2 security definition %s needs @authorizationUrl", name)
+		case scheme.Type == "oauth2" && scheme.Flow != "implicit" && scheme.TokenURL == "":
+			p.report(positions[name], SeverityError, CodeSecurity, "oauth2 security definition %s needs @tokenUrl", name)
+		}
+	}
+}
+
+// checkSecurity returns an error when requirements name a scheme that is not declared, or a scope
+// that its oauth2 scheme does not declare.
+func (p *Parser) checkSecurity(requirements []map[string][]string) error {
+	for _, requirement := range requirements {
+		for name, scopes := range requirement {
+			scheme, ok := p.swagger.SecurityDefinitions[name]
+			if !ok {
+				return fmt.Errorf("unknown security definition %s", name)
+			}
+			for _, scope := range scopes {
+				if _, ok := scheme.Scopes[scope]; !ok {
+					return fmt.Errorf("unknown scope %s of security definition %s", scope, name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ParseSecurityComment parses the requirements of a @Security comment: schemes joined by && must all be
+// satisfied, alternatives are separated by || or written on their own @Security lines.
+// @Security ApiKeyAuth
+// @Security OAuth2[read,write] || BasicAuth
+// @Security ApiKeyAuth && OAuth2[admin]
+func ParseSecurityComment(commentLine string) ([]map[string][]string, error) {
+	var requirements []map[string][]string
+	for _, alternative := range strings.Split(commentLine, "||") {
+		requirement := make(map[string][]string)
+		for _, scheme := range strings.Split(alternative, "&&") {
+			matches := securityRequirementRegexp.FindStringSubmatch(strings.TrimSpace(scheme))
+			if len(matches) != 3 {
+				return nil, fmt.Errorf("Can not parse security comment \"%s\".", commentLine)
+			}
+			scopes := []string{}
+			for _, scope := range strings.Split(matches[2], ",") {
+				if scope = strings.TrimSpace(scope); scope != "" {
+					scopes = append(scopes, scope)
+				}
+			}
+			requirement[matches[1]] = scopes
+		}
+		requirements = append(requirements, requirement)
+	}
+	return requirements, nil
+}
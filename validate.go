@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// ValidateSpec reports the problems of the parsed spec as diagnostics: references to undefined
+// definitions, duplicated operation ids and operations without responses.
+func (p *Parser) ValidateSpec() {
+	b, err := json.Marshal(p.swagger)
+	if err != nil {
+		p.reportAt(token.Position{}, SeverityError, CodeSpec, "%v", err)
+		return
+	}
+	var doc interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		p.reportAt(token.Position{}, SeverityError, CodeSpec, "%v", err)
+		return
+	}
+	refs := make(map[string][]string)
+	collectRefs("", doc, refs)
+	for _, ref := range sortedKeys(refs) {
+		name := strings.TrimPrefix(ref, "#/definitions/")
+		if _, ok := p.swagger.Definitions[name]; ok && name != ref {
+			continue
+		}
+		for _, pointer := range refs[ref] {
+			p.reportAt(p.pointerPosition(pointer), SeverityError, CodeSpec, "%s references the undefined %s", pointer, ref)
+		}
+	}
+
+	if p.swagger.Paths == nil {
+		return
+	}
+	ids := make(map[string]string)
+	for _, path := range sortedPaths(p.swagger.Paths.Paths) {
+		item := p.swagger.Paths.Paths[path]
+		for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete,
+			http.MethodOptions, http.MethodHead, http.MethodPatch} {
+			operation := pathItemOperation(item, method)
+			if operation == nil {
+				continue
+			}
+			handler := p.operationSources[method+" "+path]
+			if operation.Responses == nil || (operation.Responses.Default == nil && len(operation.Responses.StatusCodeResponses) == 0) {
+				p.report(handler.Pos(), SeverityError, CodeSpec, "%s %s has no response", method, path)
+			}
+			if operation.ID == "" {
+				continue
+			}
+			if first, ok := ids[operation.ID]; ok {
+				p.report(handler.Pos(), SeverityError, CodeSpec, "%s %s reuses the operation id %s of %s", method, path, operation.ID, first)
+				continue
+			}
+			ids[operation.ID] = method + " " + path
+		}
+	}
+}
+
+// pointerPosition returns the position of the handler documenting the operation a JSON pointer of the
+// spec is in, like /paths/~1pets/get/responses, or no position outside of operations.
+func (p *Parser) pointerPosition(pointer string) token.Position {
+	segments := strings.Split(pointer, "/")
+	if len(segments) < 4 || segments[1] != "paths" {
+		return token.Position{}
+	}
+	path := strings.Replace(strings.Replace(segments[2], "~1", "/", -1), "~0", "~", -1)
+	if handler, ok := p.operationSources[strings.ToUpper(segments[3])+" "+path]; ok {
+		return p.fileSet.Position(handler.Pos())
+	}
+	return token.Position{}
+}
+
+// collectRefs stores the JSON pointers of every $ref of value by the reference.
+func collectRefs(pointer string, value interface{}, refs map[string][]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			refs[ref] = append(refs[ref], pointer)
+		}
+		for key, item := range v {
+			collectRefs(pointer+"/"+strings.Replace(strings.Replace(key, "~", "~0", -1), "/", "~1", -1), item, refs)
+		}
+	case []interface{}:
+		for i, item := range v {
+			collectRefs(fmt.Sprintf("%s/%d", pointer, i), item, refs)
+		}
+	}
+}
+
+// pathItemOperation returns the operation of item for an upper case HTTP method.
+func pathItemOperation(item spec.PathItem, method string) *spec.Operation {
+	switch method {
+	case http.MethodGet:
+		return item.Get
+	case http.MethodPut:
+		return item.Put
+	case http.MethodPost:
+		return item.Post
+	case http.MethodDelete:
+		return item.Delete
+	case http.MethodOptions:
+		return item.Options
+	case http.MethodHead:
+		return item.Head
+	case http.MethodPatch:
+		return item.Patch
+	}
+	return nil
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedPaths(paths map[string]spec.PathItem) []string {
+	keys := make([]string, 0, len(paths))
+	for key := range paths {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}